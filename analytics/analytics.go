@@ -0,0 +1,307 @@
+// Package analytics finds catalog gaps in toolsearch query logs.
+//
+// Queries that return nothing, return only weak matches, or that callers
+// abandon for a reworded search usually point to a missing tool or a
+// description that does not use the caller's words. [Analyze] reads the
+// records of a query log written by toolsearch.QueryLogger and ranks each
+// kind by how often it happened:
+//
+//	records, err := toolsearch.ReadQueryLog(f)
+//	report := analytics.Analyze(records, analytics.Options{})
+//	err = report.WriteText(os.Stdout)
+//
+// No index or database is needed; the log is the only input.
+package analytics
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"iter"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jonwraymond/toolsearch"
+)
+
+// Options configures Analyze.
+type Options struct {
+	// LowScore is the top score below which a search counts as low
+	// confidence. BM25 scores depend on the catalog, so by default the
+	// threshold is the LowQuantile quantile of the top scores in the log.
+	LowScore float64
+
+	// LowQuantile picks the default threshold (default 0.1, the lowest
+	// tenth of searches with results).
+	LowQuantile float64
+
+	// Window is how soon a different query in the same session, with no
+	// selection in between, counts as a re-search (default 30s).
+	Window time.Duration
+
+	// Limit caps the queries listed in each ranking (default 20).
+	Limit int
+}
+
+// QueryStat counts the searches for one normalized query in a ranking.
+type QueryStat struct {
+	Query    string `json:"query"`
+	Count    int    `json:"count"`
+	Sessions int    `json:"sessions"` // distinct non-empty sessions
+
+	// TopScore is the mean top score; low-score ranking only.
+	TopScore float64 `json:"top_score,omitempty"`
+
+	// Shown holds the results of the latest such search; low-score
+	// ranking only.
+	Shown []string `json:"shown,omitempty"`
+
+	// Next holds the queries searched instead, most frequent first, up
+	// to three; re-search ranking only.
+	Next []string `json:"next,omitempty"`
+}
+
+// Ranking lists the queries of one kind, most frequent first.
+type Ranking struct {
+	Searches int         `json:"searches"` // searches of this kind
+	Queries  []QueryStat `json:"queries"`  // up to Options.Limit
+}
+
+// Report is the result of Analyze.
+type Report struct {
+	Searches   int `json:"searches"`
+	Selections int `json:"selections"`
+	Errors     int `json:"errors"` // failed searches, left out of the rankings
+	Sessions   int `json:"sessions"`
+
+	Threshold float64       `json:"threshold"` // low-score threshold used
+	Window    time.Duration `json:"window"`    // nanoseconds in JSON
+
+	ZeroResult Ranking `json:"zero_result"`
+	LowScore   Ranking `json:"low_score"`
+	Researched Ranking `json:"researched"`
+}
+
+const maxNext = 3
+
+// Analyze ranks zero-result queries, queries whose top score is below the
+// low-score threshold, and queries followed by a re-search. Queries are
+// grouped by their normalized form. Searches with an empty query, which
+// list the catalog, are ignored. Records are ordered by time before
+// sessions are followed, so logs may be concatenated in any order.
+func Analyze(records []toolsearch.QueryRecord, opts Options) Report {
+	if opts.LowQuantile <= 0 {
+		opts.LowQuantile = 0.1
+	}
+	if opts.Window <= 0 {
+		opts.Window = 30 * time.Second
+	}
+	if opts.Limit <= 0 {
+		opts.Limit = 20
+	}
+
+	records = slices.DeleteFunc(slices.Clone(records), func(rec toolsearch.QueryRecord) bool {
+		return rec.Selected == "" && rec.Normalized == ""
+	})
+	slices.SortStableFunc(records, func(a, b toolsearch.QueryRecord) int {
+		return a.Time.Compare(b.Time)
+	})
+
+	report := Report{Threshold: opts.LowScore, Window: opts.Window}
+	sessions := make(map[string]bool)
+	var searches []toolsearch.QueryRecord
+	var topScores []float64
+	for _, rec := range records {
+		if rec.Session != "" {
+			sessions[rec.Session] = true
+		}
+		switch {
+		case rec.Selected != "":
+			report.Selections++
+		case rec.Error != "":
+			report.Errors++
+		default:
+			report.Searches++
+			searches = append(searches, rec)
+			if len(rec.Shown) > 0 && len(rec.Scores) > 0 {
+				topScores = append(topScores, rec.Scores[0])
+			}
+		}
+	}
+	report.Sessions = len(sessions)
+	if report.Threshold <= 0 && len(topScores) > 0 {
+		slices.Sort(topScores)
+		i := min(int(opts.LowQuantile*float64(len(topScores))), len(topScores)-1)
+		report.Threshold = topScores[i]
+	}
+
+	zero, low, researched := newGroups(), newGroups(), newGroups()
+	for _, rec := range searches {
+		switch {
+		case len(rec.Shown) == 0:
+			zero.add(rec)
+		case len(rec.Scores) > 0 && rec.Scores[0] < report.Threshold:
+			low.add(rec).Shown = rec.Shown
+		}
+	}
+	for rec, next := range researches(records, opts.Window) {
+		researched.add(rec)
+		researched.next[rec.Normalized] = append(researched.next[rec.Normalized], next)
+	}
+
+	report.ZeroResult = zero.ranking(opts.Limit)
+	report.LowScore = low.ranking(opts.Limit)
+	report.Researched = researched.ranking(opts.Limit)
+	return report
+}
+
+// researches yields each search followed within window by a search for a
+// different query in the same session, with no selection or failed
+// search in between, and the query searched next.
+func researches(records []toolsearch.QueryRecord, window time.Duration) iter.Seq2[toolsearch.QueryRecord, string] {
+	return func(yield func(toolsearch.QueryRecord, string) bool) {
+		last := make(map[string]toolsearch.QueryRecord)
+		for _, rec := range records {
+			if rec.Session == "" {
+				continue
+			}
+			prev, ok := last[rec.Session]
+			isSearch := rec.Selected == "" && rec.Error == ""
+			if isSearch {
+				last[rec.Session] = rec
+			} else {
+				delete(last, rec.Session)
+			}
+			if !ok || !isSearch || prev.Normalized == rec.Normalized || rec.Time.Sub(prev.Time) > window {
+				continue
+			}
+			if !yield(prev, rec.Normalized) {
+				return
+			}
+		}
+	}
+}
+
+// groups accumulates QueryStats by normalized query.
+type groups struct {
+	searches int
+	stats    map[string]*QueryStat
+	sessions map[string]map[string]bool
+	scores   map[string]float64
+	next     map[string][]string
+}
+
+func newGroups() *groups {
+	return &groups{
+		stats:    make(map[string]*QueryStat),
+		sessions: make(map[string]map[string]bool),
+		scores:   make(map[string]float64),
+		next:     make(map[string][]string),
+	}
+}
+
+func (g *groups) add(rec toolsearch.QueryRecord) *QueryStat {
+	g.searches++
+	st, ok := g.stats[rec.Normalized]
+	if !ok {
+		st = &QueryStat{Query: rec.Normalized}
+		g.stats[rec.Normalized] = st
+		g.sessions[rec.Normalized] = make(map[string]bool)
+	}
+	st.Count++
+	if rec.Session != "" {
+		g.sessions[rec.Normalized][rec.Session] = true
+	}
+	if len(rec.Scores) > 0 {
+		g.scores[rec.Normalized] += rec.Scores[0]
+	}
+	return st
+}
+
+func (g *groups) ranking(limit int) Ranking {
+	r := Ranking{Searches: g.searches, Queries: []QueryStat{}}
+	for query, st := range g.stats {
+		st.Sessions = len(g.sessions[query])
+		if len(st.Shown) > 0 {
+			st.TopScore = g.scores[query] / float64(st.Count)
+		}
+		st.Next = mostFrequent(g.next[query], maxNext)
+		r.Queries = append(r.Queries, *st)
+	}
+	slices.SortFunc(r.Queries, func(a, b QueryStat) int {
+		return cmp.Or(
+			cmp.Compare(b.Count, a.Count),
+			cmp.Compare(b.Sessions, a.Sessions),
+			strings.Compare(a.Query, b.Query),
+		)
+	})
+	if len(r.Queries) > limit {
+		r.Queries = r.Queries[:limit]
+	}
+	return r
+}
+
+// mostFrequent returns up to n distinct values, most frequent first and
+// ties in order of first appearance.
+func mostFrequent(values []string, n int) []string {
+	counts := make(map[string]int)
+	var distinct []string
+	for _, v := range values {
+		if counts[v] == 0 {
+			distinct = append(distinct, v)
+		}
+		counts[v]++
+	}
+	slices.SortStableFunc(distinct, func(a, b string) int {
+		return cmp.Compare(counts[b], counts[a])
+	})
+	if len(distinct) > n {
+		distinct = distinct[:n]
+	}
+	return distinct
+}
+
+// WriteText renders the report as plain text: a summary line, then one
+// table per ranking.
+func (r Report) WriteText(w io.Writer) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d searches, %d selections, %d errors in %d sessions\n",
+		r.Searches, r.Selections, r.Errors, r.Sessions)
+
+	fmt.Fprintf(&sb, "\nzero results: %d searches (%s)\n", r.ZeroResult.Searches, percent(r.ZeroResult.Searches, r.Searches))
+	writeTable(&sb, r.ZeroResult, "", func(QueryStat) string { return "" })
+
+	fmt.Fprintf(&sb, "\nlow top score (below %.4f): %d searches (%s)\n", r.Threshold, r.LowScore.Searches, percent(r.LowScore.Searches, r.Searches))
+	writeTable(&sb, r.LowScore, "\tSCORE\tTOP RESULT", func(st QueryStat) string {
+		return fmt.Sprintf("\t%.4f\t%s", st.TopScore, st.Shown[0])
+	})
+
+	fmt.Fprintf(&sb, "\nre-searched within %s: %d searches (%s)\n", r.Window, r.Researched.Searches, percent(r.Researched.Searches, r.Searches))
+	writeTable(&sb, r.Researched, "\tNEXT", func(st QueryStat) string {
+		return "\t" + strings.Join(st.Next, ", ")
+	})
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func writeTable(sb *strings.Builder, r Ranking, header string, extra func(QueryStat) string) {
+	if len(r.Queries) == 0 {
+		return
+	}
+	tw := tabwriter.NewWriter(sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "  COUNT\tSESSIONS\tQUERY%s\n", header)
+	for _, st := range r.Queries {
+		fmt.Fprintf(tw, "  %d\t%d\t%s%s\n", st.Count, st.Sessions, st.Query, extra(st))
+	}
+	_ = tw.Flush()
+}
+
+func percent(n, total int) string {
+	if total == 0 {
+		return "0%"
+	}
+	return fmt.Sprintf("%.1f%%", 100*float64(n)/float64(total))
+}
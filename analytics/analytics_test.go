@@ -0,0 +1,170 @@
+package analytics
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jonwraymond/toolindex"
+	"github.com/jonwraymond/toolsearch"
+)
+
+var t0 = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func search(at time.Duration, session, query string, scores ...float64) toolsearch.QueryRecord {
+	rec := toolsearch.QueryRecord{Time: t0.Add(at), Session: session, Query: query, Normalized: query, Scores: scores}
+	for i := range scores {
+		rec.Shown = append(rec.Shown, query+"-"+string(rune('a'+i)))
+	}
+	return rec
+}
+
+func selection(at time.Duration, session, query string) toolsearch.QueryRecord {
+	return toolsearch.QueryRecord{Time: t0.Add(at), Session: session, Query: query, Normalized: query, Selected: query + "-a"}
+}
+
+func queries(r Ranking) []string {
+	var out []string
+	for _, st := range r.Queries {
+		out = append(out, st.Query)
+	}
+	return out
+}
+
+func TestAnalyze(t *testing.T) {
+	failed := search(0, "s9", "deploy")
+	failed.Error = "index budget exceeded"
+	records := []toolsearch.QueryRecord{
+		// Logs may be concatenated out of order.
+		search(3*time.Minute, "s3", "deploy app", 1.5),
+		search(5*time.Minute, "s3", "deploy service", 4),
+
+		search(0, "s1", "rotate secrets"),
+		search(5*time.Second, "s1", "rotate secret", 0.2),
+		search(10*time.Second, "s1", "vault rotate", 3),
+		selection(12*time.Second, "s1", "vault rotate"),
+
+		search(0, "s2", "rotate secrets"),
+		search(4*time.Second, "s2", "vault rotate", 3),
+		search(0, "s4", "frobnicate"),
+		search(0, "", "rotate secrets"),
+
+		search(0, "s5", "deploy app", 1.5),
+		selection(2*time.Second, "s5", "deploy app"),
+		search(3*time.Second, "s5", "deploy service", 4),
+		search(20*time.Second, "s5", "deploy service", 4),
+		failed,
+	}
+	for i := range 6 {
+		records = append(records, search(time.Hour, "", "list pods", 2+float64(i)))
+	}
+
+	report := Analyze(records, Options{})
+	if report.Searches != 18 || report.Selections != 2 || report.Errors != 1 || report.Sessions != 6 {
+		t.Errorf("totals = %d searches, %d selections, %d errors, %d sessions",
+			report.Searches, report.Selections, report.Errors, report.Sessions)
+	}
+	if report.Threshold != 1.5 || report.Window != 30*time.Second {
+		t.Errorf("threshold %v window %v, want the 10%% quantile 1.5 and 30s", report.Threshold, report.Window)
+	}
+
+	if got, want := queries(report.ZeroResult), []string{"rotate secrets", "frobnicate"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("zero-result queries = %v, want %v", got, want)
+	}
+	if st := report.ZeroResult.Queries[0]; st.Count != 3 || st.Sessions != 2 || report.ZeroResult.Searches != 4 {
+		t.Errorf("zero-result ranking = %+v", report.ZeroResult)
+	}
+
+	if got, want := queries(report.LowScore), []string{"rotate secret"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("low-score queries = %v, want %v", got, want)
+	}
+	if st := report.LowScore.Queries[0]; st.TopScore != 0.2 || !reflect.DeepEqual(st.Shown, []string{"rotate secret-a"}) {
+		t.Errorf("low-score stat = %+v", st)
+	}
+
+	if got, want := queries(report.Researched), []string{"rotate secrets", "rotate secret"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("re-searched queries = %v, want %v", got, want)
+	}
+	if st := report.Researched.Queries[0]; st.Count != 2 || st.Sessions != 2 || !reflect.DeepEqual(st.Next, []string{"vault rotate", "rotate secret"}) {
+		t.Errorf("re-search stat = %+v", st)
+	}
+}
+
+func TestAnalyze_IgnoresBrowsing(t *testing.T) {
+	var records []toolsearch.QueryRecord
+	for i, q := range []string{"alpha", "beta", "gamma", "delta", "epsilon"} {
+		at := time.Duration(i) * time.Minute
+		records = append(records, search(at, "s1", q, float64(i+1)), search(at+time.Second, "s1", "", 0, 0))
+	}
+	records = append(records, search(time.Hour, "s2", ""))
+
+	report := Analyze(records, Options{LowQuantile: 0.5})
+	if report.Searches != 5 || report.Threshold != 3 {
+		t.Errorf("searches = %d, threshold = %v, want 5 and 3", report.Searches, report.Threshold)
+	}
+	if got := queries(report.LowScore); !reflect.DeepEqual(got, []string{"alpha", "beta"}) {
+		t.Errorf("low-score queries = %v", got)
+	}
+	if report.ZeroResult.Searches != 0 || report.Researched.Searches != 0 {
+		t.Errorf("zero-result %+v, researched %+v", report.ZeroResult, report.Researched)
+	}
+}
+
+func TestAnalyze_Options(t *testing.T) {
+	records := []toolsearch.QueryRecord{
+		search(0, "s1", "a", 1),
+		search(time.Minute, "s1", "b", 2),
+		search(0, "s2", "c", 3),
+	}
+	report := Analyze(records, Options{LowScore: 2.5, Window: 2 * time.Minute, Limit: 1})
+	if got, want := queries(report.LowScore), []string{"a"}; !reflect.DeepEqual(got, want) || report.LowScore.Searches != 2 {
+		t.Errorf("low-score queries = %v of %d searches, want %v of 2", got, report.LowScore.Searches, want)
+	}
+	if got, want := queries(report.Researched), []string{"a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("re-searched queries = %v, want %v", got, want)
+	}
+}
+
+func TestAnalyze_QueryLog(t *testing.T) {
+	var buf bytes.Buffer
+	log := toolsearch.NewQueryLogger(&buf, toolsearch.QueryLogOptions{})
+	s := toolsearch.NewBM25Searcher(toolsearch.BM25Config{QueryLog: log})
+	defer func() { _ = s.Close() }()
+	docs := []toolindex.SearchDoc{
+		{ID: "k8s:rollout", DocText: "roll out a deployment", Summary: toolindex.Summary{ID: "k8s:rollout", Name: "rollout"}},
+		{ID: "vault:rotate", DocText: "rotate a secret", Summary: toolindex.Summary{ID: "vault:rotate", Name: "rotate"}},
+	}
+	for _, query := range []string{"Rotate  Credentials", "rotate secret"} {
+		if _, err := s.SearchWithOptions(query, 5, docs, toolsearch.SearchOptions{Session: "s1"}); err != nil {
+			t.Fatalf("search error: %v", err)
+		}
+	}
+	if err := log.Flush(); err != nil {
+		t.Fatalf("Flush error: %v", err)
+	}
+	records, err := toolsearch.ReadQueryLog(&buf)
+	if err != nil {
+		t.Fatalf("ReadQueryLog error: %v", err)
+	}
+
+	report := Analyze(records, Options{})
+	if got := queries(report.Researched); !reflect.DeepEqual(got, []string{"rotate credentials"}) {
+		t.Errorf("re-searched queries = %v", got)
+	}
+
+	var out bytes.Buffer
+	if err := report.WriteText(&out); err != nil {
+		t.Fatalf("WriteText error: %v", err)
+	}
+	for _, want := range []string{
+		"2 searches, 0 selections, 0 errors in 1 sessions",
+		"re-searched within 30s: 1 searches (50.0%)",
+		"rotate credentials  rotate secret",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("report missing %q:\n%s", want, out.String())
+		}
+	}
+}
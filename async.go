@@ -0,0 +1,146 @@
+package toolsearch
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jonwraymond/toolindex"
+)
+
+// asyncState tracks background rebuilds for BM25Config.AsyncRebuild.
+type asyncState struct {
+	mu         sync.Mutex
+	running    bool
+	closed     bool           // set by Close; no new rebuilds start
+	building   string         // fingerprint being built
+	next       *rebuildTarget // latest requested catalog not yet started
+	idle       chan struct{}  // closed when the worker stops
+	staleSince time.Time      // zero while the index is fresh
+	err        error          // last background rebuild failure
+
+	// beforeBuild, when set, runs before each background build. Tests use
+	// it to hold a rebuild open.
+	beforeBuild func()
+}
+
+// rebuildTarget is a catalog to index in the background.
+type rebuildTarget struct {
+	input       []toolindex.SearchDoc
+	sorted      []toolindex.SearchDoc
+	fingerprint string
+	version     string
+	truncations []TruncationReport
+}
+
+// rebuildInBackground schedules a rebuild for t and reports whether the
+// caller may search the current index now. It returns false when the
+// index has been stale for MaxStaleness and the wait for the rebuild did
+// not produce t, or when the searcher is closed, in which case the caller
+// rebuilds synchronously.
+func (s *BM25Searcher) rebuildInBackground(t rebuildTarget) bool {
+	a := &s.async
+	a.mu.Lock()
+	if a.closed {
+		// A rebuild started now would install an index after Close.
+		a.mu.Unlock()
+		return false
+	}
+	if a.staleSince.IsZero() {
+		a.staleSince = time.Now()
+	}
+	if a.building == t.fingerprint {
+		a.next = nil // anything queued is older than the build in progress
+	} else {
+		a.next = &t
+	}
+	if !a.running {
+		a.running = true
+		a.idle = make(chan struct{})
+		go s.rebuildLoop()
+	}
+	idle, since := a.idle, a.staleSince
+	a.mu.Unlock()
+
+	if s.cfg.MaxStaleness <= 0 || time.Since(since) < s.cfg.MaxStaleness {
+		return true
+	}
+	<-idle
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lastFingerprint == t.fingerprint
+}
+
+// rebuildLoop builds requested catalogs until none is pending, always
+// moving to the most recent request.
+func (s *BM25Searcher) rebuildLoop() {
+	a := &s.async
+	for {
+		a.mu.Lock()
+		t := a.next
+		a.next = nil
+		if t == nil {
+			a.running = false
+			a.building = ""
+			if a.err == nil {
+				a.staleSince = time.Time{}
+			}
+			close(a.idle)
+			a.mu.Unlock()
+			return
+		}
+		a.building = t.fingerprint
+		hook := a.beforeBuild
+		a.mu.Unlock()
+
+		if hook != nil {
+			hook()
+		}
+		err := s.observeRebuild(context.Background(), len(t.sorted), t.fingerprint, true, func() error {
+			return s.rebuildIndex(t.sorted, t.fingerprint, t.truncations)
+		})
+		if err == nil {
+			s.rememberDocs(t.input, t.version, t.sorted, t.fingerprint)
+		}
+
+		a.mu.Lock()
+		a.err = err
+		a.mu.Unlock()
+	}
+}
+
+// WaitFresh blocks until no background rebuild is running, so the index
+// reflects the most recently searched docs. It returns the error of the
+// last background rebuild, or ctx.Err() if ctx ends first. Without
+// AsyncRebuild it returns immediately.
+func (s *BM25Searcher) WaitFresh(ctx context.Context) error {
+	a := &s.async
+	a.mu.Lock()
+	running, idle := a.running, a.idle
+	a.mu.Unlock()
+
+	if running {
+		select {
+		case <-idle:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.err
+}
+
+// stopBackground drops pending rebuilds and waits for a running one.
+// With closing set, it also keeps new ones from starting.
+func (s *BM25Searcher) stopBackground(closing bool) {
+	a := &s.async
+	a.mu.Lock()
+	a.closed = a.closed || closing
+	a.next = nil
+	running, idle := a.running, a.idle
+	a.mu.Unlock()
+	if running {
+		<-idle
+	}
+}
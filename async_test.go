@@ -0,0 +1,162 @@
+package toolsearch
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jonwraymond/toolindex"
+)
+
+func asyncDocs(ids ...string) []toolindex.SearchDoc {
+	docs := make([]toolindex.SearchDoc, len(ids))
+	for i, id := range ids {
+		docs[i] = toolindex.SearchDoc{ID: id, DocText: "shared " + id, Summary: toolindex.Summary{ID: id}}
+	}
+	return docs
+}
+
+// holdRebuilds blocks background rebuilds until the returned function is
+// called.
+func holdRebuilds(s *BM25Searcher) (release func()) {
+	gate := make(chan struct{})
+	s.async.mu.Lock()
+	s.async.beforeBuild = func() { <-gate }
+	s.async.mu.Unlock()
+	return func() { close(gate) }
+}
+
+func TestAsyncRebuild_ServesStaleWhileBuilding(t *testing.T) {
+	s := NewBM25Searcher(BM25Config{AsyncRebuild: true, CacheSize: 8})
+	defer func() { _ = s.Close() }()
+
+	first, err := s.SearchWithOptions("shared", 10, asyncDocs("a", "b"), SearchOptions{})
+	if err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	if first.Stale || len(first.Hits) != 2 {
+		t.Fatalf("first build should be synchronous and fresh, got %+v", first)
+	}
+
+	release := holdRebuilds(s)
+	updated := asyncDocs("a", "b", "c")
+	stale, err := s.SearchWithOptions("shared", 10, updated, SearchOptions{})
+	if err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	if !stale.Stale || len(stale.Hits) != 2 {
+		t.Errorf("expected stale results from the previous index, got %+v", stale)
+	}
+
+	release()
+	if err := s.WaitFresh(context.Background()); err != nil {
+		t.Fatalf("WaitFresh error: %v", err)
+	}
+	fresh, err := s.SearchWithOptions("shared", 10, updated, SearchOptions{})
+	if err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	if fresh.Stale || len(fresh.Hits) != 3 {
+		t.Errorf("expected fresh results after WaitFresh, got %+v", fresh)
+	}
+	if s.IndexBuildCount() != 2 {
+		t.Errorf("IndexBuildCount = %d, want 2", s.IndexBuildCount())
+	}
+}
+
+func TestAsyncRebuild_MaxStalenessWaits(t *testing.T) {
+	s := NewBM25Searcher(BM25Config{AsyncRebuild: true, MaxStaleness: time.Nanosecond})
+	defer func() { _ = s.Close() }()
+
+	if _, err := s.Search("shared", 10, asyncDocs("a")); err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	result, err := s.SearchWithOptions("shared", 10, asyncDocs("a", "b"), SearchOptions{})
+	if err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	if result.Stale || len(result.Hits) != 2 {
+		t.Errorf("expected fresh results once max staleness is exceeded, got %+v", result)
+	}
+}
+
+func TestAsyncRebuild_LatestCatalogWins(t *testing.T) {
+	s := NewBM25Searcher(BM25Config{AsyncRebuild: true})
+	defer func() { _ = s.Close() }()
+
+	if _, err := s.Search("shared", 10, asyncDocs("a")); err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	release := holdRebuilds(s)
+	for _, docs := range [][]toolindex.SearchDoc{asyncDocs("a", "b"), asyncDocs("a", "b", "c"), asyncDocs("a", "b", "c", "d")} {
+		if _, err := s.Search("shared", 10, docs); err != nil {
+			t.Fatalf("search error: %v", err)
+		}
+	}
+	release()
+	if err := s.WaitFresh(context.Background()); err != nil {
+		t.Fatalf("WaitFresh error: %v", err)
+	}
+
+	result, err := s.SearchWithOptions("shared", 10, asyncDocs("a", "b", "c", "d"), SearchOptions{})
+	if err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	if result.Stale || len(result.Hits) != 4 {
+		t.Errorf("expected the latest catalog, got %+v", result)
+	}
+	// The first build plus at most the in-flight and the latest catalog.
+	if n := s.IndexBuildCount(); n > 3 {
+		t.Errorf("IndexBuildCount = %d, want intermediate catalogs skipped", n)
+	}
+}
+
+func TestAsyncRebuild_NoneAfterClose(t *testing.T) {
+	s := NewBM25Searcher(BM25Config{AsyncRebuild: true})
+	if _, err := s.Search("shared", 10, asyncDocs("a")); err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close error: %v", err)
+	}
+
+	// A search that saw the index before Close must not start a rebuild.
+	docs := asyncDocs("a", "b")
+	if s.rebuildInBackground(rebuildTarget{input: docs, sorted: docs, fingerprint: computeFingerprint(docs)}) {
+		t.Error("rebuildInBackground after Close let the caller use the closed index")
+	}
+	s.async.mu.Lock()
+	running := s.async.running
+	s.async.mu.Unlock()
+	if running {
+		t.Error("rebuildInBackground after Close started a rebuild")
+	}
+}
+
+func TestWaitFresh_ContextCanceled(t *testing.T) {
+	s := NewBM25Searcher(BM25Config{AsyncRebuild: true})
+	defer func() { _ = s.Close() }()
+
+	if _, err := s.Search("shared", 10, asyncDocs("a")); err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	release := holdRebuilds(s)
+	defer release()
+	if _, err := s.Search("shared", 10, asyncDocs("a", "b")); err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := s.WaitFresh(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("WaitFresh error = %v, want context.Canceled", err)
+	}
+}
+
+func TestWaitFresh_SynchronousMode(t *testing.T) {
+	s := NewBM25Searcher(BM25Config{})
+	if err := s.WaitFresh(context.Background()); err != nil {
+		t.Errorf("WaitFresh error = %v, want nil", err)
+	}
+}
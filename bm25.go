@@ -2,12 +2,17 @@
 package toolsearch
 
 import (
+	"context"
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search"
+	blevequery "github.com/blevesearch/bleve/v2/search/query"
 	"github.com/jonwraymond/toolindex"
 )
 
@@ -20,7 +25,58 @@ type BM25Config struct {
 
 	// Safety / performance controls.
 	MaxDocs       int // 0 = unlimited
-	MaxDocTextLen int // 0 = unlimited
+	MaxDocTextLen int // bytes; 0 = unlimited
+
+	// DocTextMode selects what MaxDocTextLen keeps. Truncation never
+	// splits a UTF-8 character, and splits a word only when no space or
+	// punctuation precedes the cut, as in unspaced CJK text.
+	DocTextMode DocTextMode // default DocTextPrefix
+
+	// Truncation picks the docs kept when MaxDocs or MaxIndexBytes cuts
+	// the catalog; nil keeps the lowest IDs. Dropped docs are reported by
+	// Truncations and Stats.
+	Truncation TruncationPolicy
+
+	// Query result cache. Results are keyed by index fingerprint,
+	// normalized query, limit and options, and dropped on rebuild.
+	CacheSize int           // max cached queries; 0 = disabled
+	CacheTTL  time.Duration // 0 = no expiry
+
+	// Stale-while-revalidate. With AsyncRebuild, a catalog change starts
+	// a background rebuild and searches keep using the previous index,
+	// flagging results as stale. Once the index has been stale for
+	// MaxStaleness, searches wait for the rebuild instead. The first
+	// build is always synchronous.
+	AsyncRebuild bool
+	MaxStaleness time.Duration // 0 = serve stale results until the rebuild finishes
+
+	// Memory budget. Catalogs whose estimated index size exceeds
+	// MaxIndexBytes are rejected or truncated according to BudgetMode.
+	// Estimating costs one analysis pass over each new catalog.
+	MaxIndexBytes int64      // 0 = unlimited
+	BudgetMode    BudgetMode // default BudgetReject
+
+	// Shards splits the index by doc ID hash into this many Bleve
+	// indexes, built and searched in parallel. Scores use catalog-wide
+	// statistics, so results match an unsharded index exactly.
+	Shards int // 0 or 1 = one index
+
+	// DuplicateThreshold is the similarity at which tools are near
+	// duplicates for Duplicates and SearchOptions.Collapse; see
+	// FindDuplicates.
+	DuplicateThreshold float64 // default 0.7
+
+	// Diversity reranks query results so they span namespaces; the zero
+	// value keeps BM25 order.
+	Diversity Diversity
+
+	// Observer receives search, rebuild, cache and error events; nil
+	// disables observation at no cost.
+	Observer Observer
+
+	// QueryLog records every search for offline analysis; nil disables
+	// logging.
+	QueryLog *QueryLogger
 }
 
 // BM25Searcher implements toolindex.Searcher using BM25 ranking.
@@ -32,6 +88,27 @@ type BM25Searcher struct {
 	idToSummary     map[string]toolindex.Summary
 	lastFingerprint string
 	indexBuildCount int
+
+	// Inputs of the current index, for cheap change detection. lastInput
+	// is a copy of the docs exactly as passed in; lastSorted is the
+	// sorted, capped slice that was indexed.
+	lastInput   []toolindex.SearchDoc
+	lastSorted  []toolindex.SearchDoc
+	lastVersion string
+
+	// live is set while a LiveSearcher keeps the index in sync with its
+	// catalog source; lastFingerprint then holds a "live:" generation.
+	live bool
+
+	cache *queryCache // nil when caching is disabled
+
+	buildDuration time.Duration
+	truncations   []TruncationReport // docs dropped from the current index's catalog
+	stats         *IndexStats        // nil until computed for the current index
+	dups          *duplicateIndex    // clusters of an index, computed on first use
+	size          *indexSizer        // size of lastSorted for live updates, computed on first use
+
+	async asyncState
 }
 
 // Ensure interface compliance at compile time.
@@ -52,9 +129,13 @@ func NewBM25Searcher(cfg BM25Config) *BM25Searcher {
 		cfg.TagsBoost = 2
 	}
 
-	return &BM25Searcher{
+	s := &BM25Searcher{
 		cfg: cfg,
 	}
+	if cfg.CacheSize > 0 {
+		s.cache = newQueryCache(cfg.CacheSize, cfg.CacheTTL)
+	}
+	return s
 }
 
 // Deterministic reports whether this searcher returns stable ordering.
@@ -70,6 +151,15 @@ func (s *BM25Searcher) IndexBuildCount() int {
 	return s.indexBuildCount
 }
 
+// CacheStats returns query result cache counters. It returns zero stats
+// when caching is disabled.
+func (s *BM25Searcher) CacheStats() CacheStats {
+	if s.cache == nil {
+		return CacheStats{}
+	}
+	return s.cache.snapshot()
+}
+
 // buildWeightedDoc creates a weighted document text for BM25 indexing.
 // It duplicates high-signal tokens according to their boost values to
 // bias ranking toward name, namespace, and tags.
@@ -104,8 +194,8 @@ func buildWeightedDoc(cfg BM25Config, doc toolindex.SearchDoc) string {
 
 	// Add DocText (possibly truncated)
 	docText := strings.ToLower(doc.DocText)
-	if cfg.MaxDocTextLen > 0 && len(docText) > cfg.MaxDocTextLen {
-		docText = docText[:cfg.MaxDocTextLen]
+	if cfg.MaxDocTextLen > 0 {
+		docText = truncateDocText(docText, cfg.MaxDocTextLen, cfg.DocTextMode)
 	}
 	if docText != "" {
 		parts = append(parts, docText)
@@ -116,138 +206,403 @@ func buildWeightedDoc(cfg BM25Config, doc toolindex.SearchDoc) string {
 
 // indexedDoc is the document structure indexed by Bleve.
 type indexedDoc struct {
-	Content string `json:"content"`
+	Content   string   `json:"content"`
+	Namespace string   `json:"namespace"`
+	Tags      []string `json:"tags,omitempty"`
+}
+
+// newIndexedDoc returns the indexed form of doc with its weighted content.
+func newIndexedDoc(content string, doc toolindex.SearchDoc) indexedDoc {
+	return indexedDoc{Content: content, Namespace: doc.Summary.Namespace, Tags: doc.Summary.Tags}
+}
+
+// SearchOptions refines a single SearchWithOptions call.
+type SearchOptions struct {
+	// Explain attaches a scoring explanation to every hit.
+	Explain bool
+
+	// Visibility restricts hits to the tools a caller may see; nil shows
+	// every tool. limit applies to visible tools.
+	Visibility *Visibility
+
+	// CatalogVersion identifies the docs passed in, such as a
+	// toolindex.ChangeEvent version. When it matches the version of the
+	// current index, change detection is skipped entirely, so callers must
+	// change it whenever the docs change. Empty means unversioned.
+	CatalogVersion string
+
+	// Context is passed to Observer callbacks, carrying request values
+	// such as a trace span. It does not cancel the search. Nil means
+	// context.Background().
+	Context context.Context
+
+	// Session identifies the caller in query logs, such as an agent
+	// conversation. It does not affect results.
+	Session string
+
+	// Collapse keeps only the best-ranked tool of each near-duplicate
+	// cluster (see Duplicates), listing the others as its Alternates.
+	// limit counts collapsed hits. Empty queries are not collapsed.
+	Collapse bool
+
+	// Budget returns as many of the first limit hits as fit in a number
+	// of tokens, reporting TokensUsed and Dropped; nil disables packing.
+	Budget *TokenBudget
+}
+
+func (o SearchOptions) context() context.Context {
+	if o.Context == nil {
+		return context.Background()
+	}
+	return o.Context
+}
+
+// Hit is a ranked search result with its BM25 score.
+type Hit struct {
+	Summary     toolindex.Summary `json:"summary"`
+	Score       float64           `json:"score"`
+	Explanation *Explanation      `json:"explanation,omitempty"`
+
+	// Alternates holds the other visible tools of the hit's near-duplicate
+	// cluster when the search collapses them: those that matched in rank
+	// order, then the rest by ID.
+	Alternates []toolindex.Summary `json:"alternates,omitempty"`
+}
+
+// Explanation describes how a score was computed. It mirrors the scoring
+// explanation tree reported by Bleve.
+type Explanation struct {
+	Value    float64        `json:"value"`
+	Message  string         `json:"message"`
+	Children []*Explanation `json:"children,omitempty"`
+}
+
+// SearchResult holds the ranked hits for a SearchWithOptions call.
+type SearchResult struct {
+	Hits []Hit `json:"hits"`
+
+	// Stale is set when the hits come from a previous index because an
+	// asynchronous rebuild for the requested docs is still running.
+	Stale bool `json:"stale,omitempty"`
+
+	// TokensUsed and Dropped report SearchOptions.Budget packing: the
+	// tokens the hits take and the number of hits left out.
+	TokensUsed int `json:"tokensUsed,omitempty"`
+	Dropped    int `json:"dropped,omitempty"`
+
+	fingerprint string // of the index that served the hits, for query logs
+}
+
+// Summaries returns the summaries of the hits in rank order.
+func (r SearchResult) Summaries() []toolindex.Summary {
+	out := make([]toolindex.Summary, len(r.Hits))
+	for i, hit := range r.Hits {
+		out[i] = hit.Summary
+	}
+	return out
 }
 
 // Search performs a BM25-ranked search over the provided documents.
 func (s *BM25Searcher) Search(query string, limit int, docs []toolindex.SearchDoc) ([]toolindex.Summary, error) {
-	query = strings.TrimSpace(query)
+	result, err := s.SearchWithOptions(query, limit, docs, SearchOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return result.Summaries(), nil
+}
+
+// SearchWithOptions performs a BM25-ranked search like Search, returning
+// scored hits. Hits for an empty query carry a zero score.
+func (s *BM25Searcher) SearchWithOptions(query string, limit int, docs []toolindex.SearchDoc, opts SearchOptions) (SearchResult, error) {
+	return s.search(query, limit, docs, opts, false)
+}
 
-	// 1. Sort docs by ID FIRST for determinism (before any other operations)
-	sortedDocs := sortDocsByID(docs)
+// search implements SearchWithOptions. When live is set and the index is
+// maintained by a LiveSearcher, docs are ignored.
+func (s *BM25Searcher) search(query string, limit int, docs []toolindex.SearchDoc, opts SearchOptions, live bool) (SearchResult, error) {
+	if s.cfg.Observer != nil || s.cfg.QueryLog != nil {
+		return s.observedSearch(query, limit, docs, opts, live)
+	}
+	return s.searchDocs(query, limit, docs, opts, live)
+}
 
-	// 2. Apply MaxDocs AFTER sorting for deterministic subset selection
-	if s.cfg.MaxDocs > 0 && len(sortedDocs) > s.cfg.MaxDocs {
-		sortedDocs = sortedDocs[:s.cfg.MaxDocs]
+// searchDocs is search without observation.
+func (s *BM25Searcher) searchDocs(query string, limit int, docs []toolindex.SearchDoc, opts SearchOptions, live bool) (SearchResult, error) {
+	result, err := s.rankDocs(query, limit, docs, opts, live)
+	if err != nil {
+		return SearchResult{}, err
 	}
+	return opts.Budget.Pack(result), nil
+}
 
-	// 3. Empty query returns first limit docs from sortedDocs
-	if query == "" {
-		n := limit
-		if n > len(sortedDocs) {
-			n = len(sortedDocs)
+// rankDocs returns the first limit hits for query.
+func (s *BM25Searcher) rankDocs(query string, limit int, docs []toolindex.SearchDoc, opts SearchOptions, live bool) (SearchResult, error) {
+	query = strings.TrimSpace(query)
+
+	// 1. Reuse the sorted docs and fingerprint of the current index when
+	// the catalog is unchanged, skipping the sort and hash below.
+	sortedDocs, fingerprint, unchanged := s.lookupDocs(docs, opts.CatalogVersion, live)
+	var truncations []TruncationReport
+	if !unchanged {
+		// Sort docs by ID FIRST for determinism (before any other operations)
+		sortedDocs = sortDocsByID(docs)
+
+		// 2. Apply MaxDocs and MaxIndexBytes AFTER sorting for
+		// deterministic subset selection
+		var err error
+		if sortedDocs, truncations, err = s.truncate(sortedDocs); err != nil {
+			return SearchResult{}, err
 		}
-		results := make([]toolindex.Summary, n)
-		for i := range n {
-			results[i] = sortedDocs[i].Summary
+	}
+
+	// 3. Empty query returns first limit visible docs from sortedDocs
+	if query == "" {
+		hits := make([]Hit, 0, min(max(limit, 0), len(sortedDocs)))
+		for _, doc := range sortedDocs {
+			if len(hits) >= limit {
+				break
+			}
+			if opts.Visibility.Allows(doc.Summary) {
+				hits = append(hits, Hit{Summary: doc.Summary})
+			}
 		}
-		return results, nil
+		return SearchResult{Hits: hits}, nil
 	}
 
 	// 4. No docs means no results
-	if len(sortedDocs) == 0 {
-		return []toolindex.Summary{}, nil
-	}
-	if limit <= 0 {
-		return []toolindex.Summary{}, nil
+	if len(sortedDocs) == 0 || limit <= 0 {
+		return SearchResult{Hits: []Hit{}}, nil
 	}
 
-	// 5. Compute fingerprint from sortedDocs (already sorted)
-	fingerprint := computeFingerprint(sortedDocs)
+	if !unchanged {
+		// 5. Compute fingerprint from sortedDocs (already sorted)
+		fingerprint = computeFingerprint(sortedDocs)
+
+		// 6. Check if we need to rebuild the index
+		s.mu.RLock()
+		hasIndex := s.index != nil
+		needsRebuild := !hasIndex || s.lastFingerprint != fingerprint
+		s.mu.RUnlock()
+
+		// 7. In async mode, keep serving the previous index while the new
+		// one builds, unless it has been stale for longer than allowed.
+		if needsRebuild && hasIndex && s.cfg.AsyncRebuild {
+			needsRebuild = !s.rebuildInBackground(rebuildTarget{
+				input:       docs,
+				sorted:      sortedDocs,
+				fingerprint: fingerprint,
+				version:     opts.CatalogVersion,
+				truncations: truncations,
+			})
+		}
+
+		// Rebuild uses sortedDocs
+		if needsRebuild {
+			err := s.observeRebuild(opts.context(), len(sortedDocs), fingerprint, false, func() error {
+				return s.rebuildIndex(sortedDocs, fingerprint, truncations)
+			})
+			if err != nil {
+				return SearchResult{}, err
+			}
+		}
+		s.rememberDocs(docs, opts.CatalogVersion, sortedDocs, fingerprint)
+	}
 
-	// 6. Check if we need to rebuild the index
-	s.mu.RLock()
-	needsRebuild := s.index == nil || s.lastFingerprint != fingerprint
-	s.mu.RUnlock()
+	if limit > len(sortedDocs) {
+		limit = len(sortedDocs)
+	}
 
-	// 7. Rebuild uses sortedDocs
-	if needsRebuild {
-		if err := s.rebuildIndex(sortedDocs, fingerprint); err != nil {
-			return nil, err
+	// 8. Serve repeated queries from the cache
+	var key string
+	if s.cache != nil {
+		key = cacheKey(fingerprint, query, limit, opts)
+		if hits, ok := s.cache.get(key); ok {
+			if obs := s.cfg.Observer; obs != nil {
+				obs.CacheHit(opts.context(), CacheHitEvent{Query: query, Limit: limit, Hits: len(hits)})
+			}
+			return SearchResult{Hits: hits, fingerprint: fingerprint}, nil
 		}
 	}
 
+	var dups *duplicateIndex
+	if opts.Collapse {
+		dups = s.duplicates()
+	}
+
 	// Execute search with read lock
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
+	// The index lags the requested docs while an async rebuild runs.
+	stale := s.lastFingerprint != fingerprint
+
+	// Fetch enough hits to fill limit after collapsing and to rerank for
+	// diversity, using the clusters of the index being searched.
+	pool := s.cfg.Diversity.pool(limit)
+	size := pool
+	if dups != nil {
+		if dups.fingerprint != s.lastFingerprint {
+			dups = newDuplicateIndex(s.lastSorted, s.lastFingerprint, DuplicateOptions{Threshold: s.cfg.DuplicateThreshold})
+		}
+		size += dups.extra
+	}
+
 	// Normalize query
 	query = strings.ToLower(query)
 
-	// 8. Search uses a plain match query to avoid query syntax injection.
+	// 9. Search uses a plain match query to avoid query syntax injection.
+	// Visibility is a non-scoring filter, so scores match an unfiltered
+	// search and limit counts visible tools only.
 	matchQuery := bleve.NewMatchQuery(query)
-	matchQuery.SetField("content")
-	searchRequest := bleve.NewSearchRequest(matchQuery)
-	if limit > len(sortedDocs) {
-		limit = len(sortedDocs)
+	matchQuery.SetField(contentField)
+	var q blevequery.Query = matchQuery
+	if filter := opts.Visibility.filter(); filter != nil {
+		bq := bleve.NewBooleanQuery()
+		bq.AddMust(matchQuery)
+		bq.AddFilter(filter)
+		q = bq
 	}
-	searchRequest.Size = limit
+	searchRequest := bleve.NewSearchRequest(q)
+	searchRequest.Size = size
+	searchRequest.Explain = opts.Explain
 	searchRequest.SortBy([]string{"-_score", "_id"})
 	searchResult, err := s.index.Search(searchRequest)
 	if err != nil {
-		return nil, err
+		return SearchResult{}, err
+	}
+	// A sharded index reports failed shards in the status.
+	for name, err := range searchResult.Status.Errors {
+		return SearchResult{}, fmt.Errorf("%s: %w", name, err)
 	}
 
 	// Collect hits with scores for deterministic tie-breaking
-	type scoredHit struct {
-		id    string
-		score float64
-	}
-	hits := make([]scoredHit, 0, len(searchResult.Hits))
+	hits := make([]Hit, 0, len(searchResult.Hits))
 	for _, hit := range searchResult.Hits {
-		if _, ok := s.idToSummary[hit.ID]; ok {
-			hits = append(hits, scoredHit{id: hit.ID, score: hit.Score})
+		if summary, ok := s.idToSummary[hit.ID]; ok {
+			hits = append(hits, Hit{Summary: summary, Score: hit.Score, Explanation: convertExplanation(hit.Expl)})
 		}
 	}
 
 	// Sort: score DESC, then ID ASC for tie-breaking
 	sort.Slice(hits, func(i, j int) bool {
-		if hits[i].score != hits[j].score {
-			return hits[i].score > hits[j].score
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
 		}
-		return hits[i].id < hits[j].id
+		return hits[i].Summary.ID < hits[j].Summary.ID
 	})
 
-	// Apply limit and map to summaries
-	if len(hits) > limit {
-		hits = hits[:limit]
+	// Apply limit
+	if dups != nil {
+		hits = dups.collapse(hits, pool, s.idToSummary, opts.Visibility)
+	} else if len(hits) > pool {
+		hits = hits[:pool]
 	}
-	results := make([]toolindex.Summary, len(hits))
-	for i, hit := range hits {
-		results[i] = s.idToSummary[hit.id]
+	hits = s.cfg.Diversity.rerank(hits, limit)
+
+	// Only cache results computed against the requested fingerprint; a
+	// concurrent rebuild may have swapped the index since step 6.
+	if s.cache != nil && !stale {
+		s.cache.put(key, hits)
 	}
 
-	return results, nil
+	return SearchResult{Hits: hits, Stale: stale, fingerprint: s.lastFingerprint}, nil
 }
 
-// rebuildIndex creates a new Bleve index from the given documents.
-func (s *BM25Searcher) rebuildIndex(docs []toolindex.SearchDoc, fingerprint string) error {
-	// Build ID to Summary map and create in-memory Bleve index
-	idToSummary := make(map[string]toolindex.Summary, len(docs))
-	index, err := bleve.NewMemOnly(bleve.NewIndexMapping())
-	if err != nil {
-		return err
+// lookupDocs returns the sorted docs and fingerprint of the current index
+// if docs is the catalog it was built from: either the index is live and
+// the caller accepts it, version matches the recorded catalog version, or
+// docs equals the previous input field by field. Unchanged strings share
+// memory with the previous input, so the comparison is far cheaper than
+// sorting and hashing.
+func (s *BM25Searcher) lookupDocs(docs []toolindex.SearchDoc, version string, live bool) ([]toolindex.SearchDoc, string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.index == nil || s.lastSorted == nil {
+		return nil, "", false
+	}
+	if (live && s.live) || (version != "" && version == s.lastVersion) || (s.lastInput != nil && sameDocs(docs, s.lastInput)) {
+		return s.lastSorted, s.lastFingerprint, true
 	}
+	return nil, "", false
+}
 
-	// Index documents
-	batch := index.NewBatch()
-	for _, doc := range docs {
-		idToSummary[doc.ID] = doc.Summary
-		weightedText := buildWeightedDoc(s.cfg, doc)
-		if err := batch.Index(doc.ID, indexedDoc{Content: weightedText}); err != nil {
-			if cerr := index.Close(); cerr != nil {
-				return fmt.Errorf("%w; close index: %v", err, cerr)
-			}
-			return err
+// rememberDocs records docs as the input of the index with fingerprint.
+func (s *BM25Searcher) rememberDocs(docs []toolindex.SearchDoc, version string, sorted []toolindex.SearchDoc, fingerprint string) {
+	// Copy outside the lock; tag slices are cloned so later in-place
+	// edits by the caller are detected.
+	input := make([]toolindex.SearchDoc, len(docs))
+	for i, doc := range docs {
+		doc.Summary.Tags = slices.Clone(doc.Summary.Tags)
+		input[i] = doc
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.lastFingerprint != fingerprint {
+		return // a concurrent rebuild installed other docs
+	}
+	s.lastInput = input
+	s.lastSorted = sorted
+	s.lastVersion = version
+}
+
+// sameDocs reports whether a and b hold equal docs in the same order.
+func sameDocs(a, b []toolindex.SearchDoc) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		x, y := &a[i], &b[i]
+		if x.ID != y.ID || x.DocText != y.DocText ||
+			x.Summary.ID != y.Summary.ID ||
+			x.Summary.Name != y.Summary.Name ||
+			x.Summary.Namespace != y.Summary.Namespace ||
+			x.Summary.ShortDescription != y.Summary.ShortDescription ||
+			!slices.Equal(x.Summary.Tags, y.Summary.Tags) {
+			return false
 		}
 	}
-	if err := index.Batch(batch); err != nil {
-		if cerr := index.Close(); cerr != nil {
-			return fmt.Errorf("%w; close index: %v", err, cerr)
+	return true
+}
+
+// convertExplanation copies a Bleve explanation tree.
+func convertExplanation(expl *search.Explanation) *Explanation {
+	if expl == nil {
+		return nil
+	}
+	out := &Explanation{Value: expl.Value, Message: expl.Message}
+	for _, child := range expl.Children {
+		if c := convertExplanation(child); c != nil {
+			out.Children = append(out.Children, c)
 		}
+	}
+	return out
+}
+
+// rebuildIndex creates a new Bleve index from the given documents, which
+// are what remains of a catalog after truncations.
+func (s *BM25Searcher) rebuildIndex(docs []toolindex.SearchDoc, fingerprint string, truncations []TruncationReport) error {
+	start := time.Now()
+
+	// Build ID to Summary map and create in-memory Bleve index
+	idToSummary := make(map[string]toolindex.Summary, len(docs))
+	for _, doc := range docs {
+		idToSummary[doc.ID] = doc.Summary
+	}
+	index, err := newIndex(s.cfg.Shards)
+	if err != nil {
 		return err
 	}
 
+	// Index documents, weighting each shard's docs on its own goroutine
+	err = buildIndex(index, docs, func(doc toolindex.SearchDoc) interface{} {
+		return newIndexedDoc(buildWeightedDoc(s.cfg, doc), doc)
+	})
+	if err != nil {
+		return closeOnError(index, err)
+	}
+
 	// Atomically swap in the new index
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -274,6 +629,13 @@ func (s *BM25Searcher) rebuildIndex(docs []toolindex.SearchDoc, fingerprint stri
 	s.idToSummary = idToSummary
 	s.lastFingerprint = fingerprint
 	s.indexBuildCount++
+	s.buildDuration, s.stats, s.dups, s.size = time.Since(start), nil, nil, nil
+	s.truncations = truncations
+	s.lastInput, s.lastSorted, s.lastVersion = nil, docs, ""
+	s.live = false
+	if s.cache != nil {
+		s.cache.purge()
+	}
 
 	return nil
 }
@@ -288,8 +650,11 @@ func sortDocsByID(docs []toolindex.SearchDoc) []toolindex.SearchDoc {
 	return sorted
 }
 
-// Close releases resources held by the searcher.
+// Close releases resources held by the searcher. It waits for a running
+// background rebuild to finish; later searches rebuild synchronously.
 func (s *BM25Searcher) Close() error {
+	s.stopBackground(true)
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -298,6 +663,15 @@ func (s *BM25Searcher) Close() error {
 		s.index = nil
 		s.idToSummary = nil
 		s.lastFingerprint = ""
+		s.lastInput = nil
+		s.lastSorted = nil
+		s.lastVersion = ""
+		s.live = false
+		s.buildDuration, s.stats, s.dups, s.size = 0, nil, nil, nil
+		s.truncations = nil
+		if s.cache != nil {
+			s.cache.purge()
+		}
 		return err
 	}
 	return nil
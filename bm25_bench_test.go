@@ -104,3 +104,83 @@ func BenchmarkSearch_VaryingCatalogSize(b *testing.B) {
 		})
 	}
 }
+
+func BenchmarkSearch_CachedQuery(b *testing.B) {
+	s := NewBM25Searcher(BM25Config{CacheSize: 128})
+	docs := makeBenchDocs(1000)
+
+	// Warm up the index and the cache entry
+	if _, err := s.Search("kubernetes", 10, docs); err != nil {
+		b.Fatalf("warmup search failed: %v", err)
+	}
+
+	b.ResetTimer()
+	for b.Loop() {
+		if _, err := s.Search("kubernetes", 10, docs); err != nil {
+			b.Fatalf("search failed: %v", err)
+		}
+	}
+}
+
+func BenchmarkSearch_CachedQuery_CatalogVersion(b *testing.B) {
+	sizes := []int{100, 1000, 10000}
+
+	for _, size := range sizes {
+		b.Run(fmt.Sprintf("size_%d", size), func(b *testing.B) {
+			s := NewBM25Searcher(BM25Config{CacheSize: 128})
+			docs := makeBenchDocs(size)
+			opts := SearchOptions{CatalogVersion: "1"}
+
+			// Warm up the index and the cache entry
+			if _, err := s.SearchWithOptions("kubernetes", 10, docs, opts); err != nil {
+				b.Fatalf("warmup search failed: %v", err)
+			}
+
+			b.ResetTimer()
+			for b.Loop() {
+				if _, err := s.SearchWithOptions("kubernetes", 10, docs, opts); err != nil {
+					b.Fatalf("search failed: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkRebuild_Shards(b *testing.B) {
+	docs := makeBenchDocs(10000)
+
+	for _, shards := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("shards_%d", shards), func(b *testing.B) {
+			for b.Loop() {
+				s := NewBM25Searcher(BM25Config{Shards: shards})
+				if _, err := s.Search("kubernetes", 10, docs); err != nil {
+					b.Fatalf("search failed: %v", err)
+				}
+				_ = s.Close()
+			}
+		})
+	}
+}
+
+func BenchmarkSearch_Shards(b *testing.B) {
+	docs := makeBenchDocs(10000)
+
+	for _, shards := range []int{1, 4} {
+		b.Run(fmt.Sprintf("shards_%d", shards), func(b *testing.B) {
+			s := NewBM25Searcher(BM25Config{Shards: shards})
+			defer func() { _ = s.Close() }()
+
+			// Warm up
+			if _, err := s.Search("git docker", 10, docs); err != nil {
+				b.Fatalf("warmup search failed: %v", err)
+			}
+
+			b.ResetTimer()
+			for b.Loop() {
+				if _, err := s.Search("git docker kubernetes", 10, docs); err != nil {
+					b.Fatalf("search failed: %v", err)
+				}
+			}
+		})
+	}
+}
@@ -2,9 +2,11 @@ package toolsearch
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 	"sync"
 	"testing"
+	"unicode/utf8"
 
 	"github.com/jonwraymond/toolindex"
 )
@@ -890,6 +892,32 @@ func TestSearch_UnicodeContent(t *testing.T) {
 	if len(results) == 0 {
 		t.Log("Note: café query returned 0 results (tokenization dependent)")
 	}
+
+	// Truncation inside a multi-byte character keeps valid UTF-8 and
+	// drops the partial word.
+	cfg := BM25Config{MaxDocTextLen: 13}
+	for _, doc := range docs {
+		content := buildWeightedDoc(cfg, doc)
+		if !utf8.ValidString(content) {
+			t.Errorf("%s: truncated content is not valid UTF-8: %q", doc.ID, content)
+		}
+	}
+	if got := truncateDocText("café résumé naïve tool", 13, DocTextPrefix); got != "café" {
+		t.Errorf("truncated accented text = %q, want %q", got, "café")
+	}
+	if got := truncateDocText("🚀 rocket", 2, DocTextPrefix); got != "" {
+		t.Errorf("truncated emoji = %q, want empty", got)
+	}
+
+	truncating := NewBM25Searcher(cfg)
+	defer func() { _ = truncating.Close() }()
+	results, err = truncating.Search("résumé", 10, docs)
+	if err != nil {
+		t.Fatalf("Search error for résumé: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("expected résumé to be truncated away, got %v", results)
+	}
 }
 
 func TestSearch_VeryLongDocText(t *testing.T) {
@@ -935,6 +963,29 @@ func TestSearch_VeryLongDocText_WithTruncation(t *testing.T) {
 	if len(results) != 0 {
 		t.Errorf("expected 0 results (word should be truncated), got %d", len(results))
 	}
+
+	// The cut falls inside "padding"; the fragment must not be indexed.
+	results, err = s.Search("padd", 10, docs)
+	if err != nil {
+		t.Fatalf("Search error: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("expected no match for a word fragment, got %d", len(results))
+	}
+	if got := truncateDocText(longText, 100, DocTextPrefix); got != strings.TrimSpace(strings.Repeat("padding ", 12)) {
+		t.Errorf("truncated text = %q, want 12 whole words", got)
+	}
+
+	// In sections mode the parameter docs survive a long preamble.
+	sectioned := NewBM25Searcher(BM25Config{MaxDocTextLen: 100, DocTextMode: DocTextSections})
+	docs[0].DocText = "upload a file.\n\n" + strings.Repeat("padding ", 100) + "\n\nparameters:\n- bucket: target bucket"
+	results, err = sectioned.Search("bucket", 10, docs)
+	if err != nil {
+		t.Fatalf("Search error: %v", err)
+	}
+	if len(results) != 1 {
+		t.Errorf("expected parameter docs to be kept in sections mode, got %d results", len(results))
+	}
 }
 
 // Integration test demonstrating toolindex.Searcher compatibility
@@ -1015,3 +1066,186 @@ func TestBM25Searcher_ToolindexIntegration(t *testing.T) {
 		t.Fatalf("close failed: %v", err)
 	}
 }
+
+// Scored and explained results
+
+func TestSearchWithOptions_ScoresMatchSearchOrder(t *testing.T) {
+	s := NewBM25Searcher(BM25Config{})
+	docs := []toolindex.SearchDoc{
+		{ID: "a", DocText: "deploy service", Summary: toolindex.Summary{ID: "a", Name: "deploy"}},
+		{ID: "b", DocText: "rollback deploy", Summary: toolindex.Summary{ID: "b", Name: "rollback"}},
+		{ID: "c", DocText: "unrelated", Summary: toolindex.Summary{ID: "c", Name: "other"}},
+	}
+
+	summaries, err := s.Search("deploy", 10, docs)
+	if err != nil {
+		t.Fatalf("Search error: %v", err)
+	}
+	result, err := s.SearchWithOptions("deploy", 10, docs, SearchOptions{})
+	if err != nil {
+		t.Fatalf("SearchWithOptions error: %v", err)
+	}
+
+	if len(result.Hits) != len(summaries) {
+		t.Fatalf("got %d hits, want %d", len(result.Hits), len(summaries))
+	}
+	for i, hit := range result.Hits {
+		if hit.Summary.ID != summaries[i].ID {
+			t.Errorf("hit %d = %s, want %s", i, hit.Summary.ID, summaries[i].ID)
+		}
+		if hit.Score <= 0 {
+			t.Errorf("hit %d has non-positive score %v", i, hit.Score)
+		}
+		if hit.Explanation != nil {
+			t.Errorf("hit %d has an explanation without Explain", i)
+		}
+	}
+	if result.Hits[0].Score < result.Hits[1].Score {
+		t.Error("hits are not ordered by score")
+	}
+}
+
+func TestSearchWithOptions_Explain(t *testing.T) {
+	s := NewBM25Searcher(BM25Config{})
+	docs := makeTestDocs(3)
+
+	result, err := s.SearchWithOptions("tool", 3, docs, SearchOptions{Explain: true})
+	if err != nil {
+		t.Fatalf("SearchWithOptions error: %v", err)
+	}
+	if len(result.Hits) == 0 {
+		t.Fatal("expected hits")
+	}
+	for _, hit := range result.Hits {
+		if hit.Explanation == nil {
+			t.Fatalf("hit %s has no explanation", hit.Summary.ID)
+		}
+		if hit.Explanation.Value != hit.Score {
+			t.Errorf("explanation value %v != score %v", hit.Explanation.Value, hit.Score)
+		}
+		if len(hit.Explanation.Children) == 0 {
+			t.Errorf("explanation for %s has no children", hit.Summary.ID)
+		}
+	}
+}
+
+func TestSearchWithOptions_EmptyQueryZeroScores(t *testing.T) {
+	s := NewBM25Searcher(BM25Config{})
+
+	result, err := s.SearchWithOptions("", 2, makeTestDocs(5), SearchOptions{Explain: true})
+	if err != nil {
+		t.Fatalf("SearchWithOptions error: %v", err)
+	}
+	if len(result.Hits) != 2 {
+		t.Fatalf("got %d hits, want 2", len(result.Hits))
+	}
+	for _, hit := range result.Hits {
+		if hit.Score != 0 || hit.Explanation != nil {
+			t.Errorf("empty-query hit %s has score %v, explanation %v", hit.Summary.ID, hit.Score, hit.Explanation)
+		}
+	}
+
+	result, err = s.SearchWithOptions("", -1, makeTestDocs(5), SearchOptions{})
+	if err != nil {
+		t.Fatalf("SearchWithOptions error: %v", err)
+	}
+	if len(result.Hits) != 0 {
+		t.Errorf("negative limit returned %d hits", len(result.Hits))
+	}
+}
+
+func TestSearch_UnchangedDocsSkipFingerprint(t *testing.T) {
+	s := NewBM25Searcher(BM25Config{})
+	docs := makeTestDocs(5)
+
+	if _, err := s.Search("tool", 3, docs); err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	sorted := s.lastSorted
+
+	// A fresh copy sharing the same strings takes the fast path.
+	if _, err := s.Search("tool", 3, slices.Clone(docs)); err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	if &s.lastSorted[0] != &sorted[0] {
+		t.Error("expected unchanged docs to reuse the sorted snapshot")
+	}
+	if s.IndexBuildCount() != 1 {
+		t.Errorf("IndexBuildCount = %d, want 1", s.IndexBuildCount())
+	}
+}
+
+func TestSearch_InPlaceEditsDetected(t *testing.T) {
+	s := NewBM25Searcher(BM25Config{})
+	docs := []toolindex.SearchDoc{
+		{ID: "a", DocText: "alpha", Summary: toolindex.Summary{ID: "a", Tags: []string{"first"}}},
+		{ID: "b", DocText: "beta", Summary: toolindex.Summary{ID: "b", Tags: []string{"second"}}},
+	}
+	if _, err := s.Search("alpha", 10, docs); err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+
+	docs[1].DocText = "alpha beta"
+	results, err := s.Search("alpha", 10, docs)
+	if err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Errorf("DocText edit not detected: got %d results, want 2", len(results))
+	}
+
+	docs[0].Summary.Tags[0] = "renamed"
+	results, err = s.Search("alpha", 10, docs)
+	if err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	if results[0].Tags[0] != "renamed" {
+		t.Errorf("in-place tag edit not detected: got tags %v", results[0].Tags)
+	}
+	if s.IndexBuildCount() != 3 {
+		t.Errorf("IndexBuildCount = %d, want 3", s.IndexBuildCount())
+	}
+}
+
+func TestSearchWithOptions_CatalogVersion(t *testing.T) {
+	s := NewBM25Searcher(BM25Config{})
+	docs := makeTestDocs(3)
+
+	if _, err := s.SearchWithOptions("tool", 10, docs, SearchOptions{CatalogVersion: "v1"}); err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+
+	// The same version is trusted without inspecting the docs.
+	result, err := s.SearchWithOptions("tool", 10, docs[:1], SearchOptions{CatalogVersion: "v1"})
+	if err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	if len(result.Hits) != 3 {
+		t.Errorf("got %d hits for a matching version, want 3 from the indexed catalog", len(result.Hits))
+	}
+
+	// A new version is checked and rebuilt.
+	result, err = s.SearchWithOptions("tool", 10, docs[:1], SearchOptions{CatalogVersion: "v2"})
+	if err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	if len(result.Hits) != 1 || s.IndexBuildCount() != 2 {
+		t.Errorf("got %d hits and %d builds after a version change, want 1 and 2", len(result.Hits), s.IndexBuildCount())
+	}
+}
+
+func TestSearch_EmptyDocsAfterBuild(t *testing.T) {
+	s := NewBM25Searcher(BM25Config{})
+	if _, err := s.Search("tool", 10, makeTestDocs(3)); err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	for _, q := range []string{"tool", ""} {
+		results, err := s.Search(q, 10, nil)
+		if err != nil {
+			t.Fatalf("search error: %v", err)
+		}
+		if len(results) != 0 {
+			t.Errorf("Search(%q) over no docs = %v, want none", q, results)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package toolsearch
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis"
+	"github.com/jonwraymond/toolindex"
+)
+
+// BudgetMode selects what happens to a catalog whose index would exceed
+// BM25Config.MaxIndexBytes.
+type BudgetMode int
+
+const (
+	// BudgetReject fails searches over the catalog with an
+	// *IndexBudgetError.
+	BudgetReject BudgetMode = iota
+
+	// BudgetTruncate indexes the docs that fit the budget, in the order
+	// of BM25Config.Truncation, and drops the rest.
+	BudgetTruncate
+)
+
+// ErrIndexBudget is matched by every *IndexBudgetError.
+var ErrIndexBudget = errors.New("index exceeds memory budget")
+
+// IndexBudgetError reports a catalog whose index would exceed
+// MaxIndexBytes.
+type IndexBudgetError struct {
+	Limit     int64 // MaxIndexBytes
+	Estimated int64 // approximate bytes the whole catalog needs
+	Docs      int   // documents in the catalog
+}
+
+func (e *IndexBudgetError) Error() string {
+	return fmt.Sprintf("%v: %d docs need ~%d bytes, limit is %d", ErrIndexBudget, e.Docs, e.Estimated, e.Limit)
+}
+
+// Unwrap returns ErrIndexBudget.
+func (e *IndexBudgetError) Unwrap() error {
+	return ErrIndexBudget
+}
+
+// IndexStats describes the current index.
+type IndexStats struct {
+	Docs          int           `json:"docs"`
+	Terms         int           `json:"terms"`        // distinct indexed terms
+	ApproxBytes   int64         `json:"approx_bytes"` // estimated index memory
+	BuildDuration time.Duration `json:"build_duration"`
+	TruncatedDocs int           `json:"truncated_docs"` // dropped by MaxDocs or MaxIndexBytes
+}
+
+// Stats returns the size of the current index. Term and byte counts are
+// computed on first use after each change, which costs about as much as
+// analyzing the catalog once. It returns zero stats before the first
+// build.
+func (s *BM25Searcher) Stats() IndexStats {
+	s.mu.RLock()
+	if s.index == nil || s.lastSorted == nil {
+		s.mu.RUnlock()
+		return IndexStats{}
+	}
+	if s.stats != nil {
+		st := *s.stats
+		s.mu.RUnlock()
+		return st
+	}
+	sorted, fingerprint, took := s.lastSorted, s.lastFingerprint, s.buildDuration
+	truncated := 0
+	for _, r := range s.truncations {
+		truncated += len(r.Dropped)
+	}
+	s.mu.RUnlock()
+
+	z := newIndexSizer()
+	for _, doc := range sorted {
+		z.add(doc, buildWeightedDoc(s.cfg, doc))
+	}
+	st := IndexStats{Docs: len(sorted), Terms: len(z.terms), ApproxBytes: z.bytes, BuildDuration: took, TruncatedDocs: truncated}
+
+	s.mu.Lock()
+	if s.lastFingerprint == fingerprint {
+		s.stats = &st
+	}
+	s.mu.Unlock()
+	return st
+}
+
+// fitBudget applies MaxIndexBytes to sorted docs, returning the docs to
+// index sorted by ID. With BudgetTruncate it keeps docs in the order of
+// the truncation policy until the budget is spent.
+func (s *BM25Searcher) fitBudget(sorted []toolindex.SearchDoc) ([]toolindex.SearchDoc, error) {
+	err := s.checkBudget(sorted)
+	if err == nil || s.cfg.BudgetMode != BudgetTruncate {
+		return sorted, err
+	}
+
+	ordered := s.prioritize(sorted, len(sorted))
+	z := newIndexSizer()
+	n := 0
+	for _, doc := range ordered {
+		z.add(doc, buildWeightedDoc(s.cfg, doc))
+		if z.bytes > s.cfg.MaxIndexBytes {
+			break
+		}
+		n++
+	}
+	return sortDocsByID(ordered[:n]), nil
+}
+
+// checkBudget returns an *IndexBudgetError if the whole of docs exceeds
+// MaxIndexBytes, regardless of BudgetMode.
+func (s *BM25Searcher) checkBudget(docs []toolindex.SearchDoc) error {
+	if s.cfg.MaxIndexBytes <= 0 {
+		return nil
+	}
+	z := newIndexSizer()
+	for _, doc := range docs {
+		z.add(doc, buildWeightedDoc(s.cfg, doc))
+	}
+	if z.bytes > s.cfg.MaxIndexBytes {
+		return &IndexBudgetError{Limit: s.cfg.MaxIndexBytes, Estimated: z.bytes, Docs: len(docs)}
+	}
+	return nil
+}
+
+// Approximate in-memory costs of the rows Bleve's upsidedown index keeps
+// per document and term, including the store's tree node for each row.
+// Every term is indexed twice: in the content field and in the _all
+// composite field.
+const (
+	rowOverhead        = 80  // tree node and key prefix per stored row
+	docOverhead        = 320 // summary map entry and doc-level rows
+	termOccurrenceSize = 16  // term vector entry per token
+	indexedFields      = 2
+)
+
+// indexSizer accumulates the estimated index size of a set of docs.
+type indexSizer struct {
+	analyzer analysis.Analyzer
+	terms    map[string]int // docs containing each term
+	bytes    int64
+}
+
+func newIndexSizer() *indexSizer {
+	return &indexSizer{
+		analyzer: bleve.NewIndexMapping().AnalyzerNamed("standard"),
+		terms:    make(map[string]int),
+	}
+}
+
+// add accounts for one doc with its weighted content.
+func (z *indexSizer) add(doc toolindex.SearchDoc, content string) {
+	z.account(doc, content, 1)
+}
+
+// remove takes back the cost of a doc added with the same content.
+func (z *indexSizer) remove(doc toolindex.SearchDoc, content string) {
+	z.account(doc, content, -1)
+}
+
+func (z *indexSizer) account(doc toolindex.SearchDoc, content string, sign int) {
+	id := int64(len(doc.ID))
+	sum := doc.Summary
+	n := docOverhead + int64(len(sum.ID)+len(sum.Name)+len(sum.Namespace)+len(sum.ShortDescription))
+	for _, tag := range sum.Tags {
+		n += int64(len(tag)) + 16
+	}
+	// Stored content row, and keyword rows for the visibility fields.
+	n += rowOverhead + id + int64(len(content))
+	for _, kw := range append([]string{sum.Namespace}, sum.Tags...) {
+		n += rowOverhead + id + int64(len(kw))
+	}
+
+	freq := make(map[string]int)
+	for _, tok := range z.analyzer.Analyze([]byte(content)) {
+		freq[string(tok.Term)]++
+	}
+	for term, count := range freq {
+		t := int64(len(term))
+		// Term frequency row with term vectors, and the back index entry.
+		n += indexedFields * (rowOverhead + t + id + int64(count)*termOccurrenceSize)
+		n += t + 8
+		// Dictionary row, shared by the docs containing the term.
+		before := z.terms[term]
+		if after := before + sign; after > 0 {
+			z.terms[term] = after
+		} else {
+			delete(z.terms, term)
+		}
+		if before == 0 || before+sign == 0 {
+			n += indexedFields * (rowOverhead + t)
+		}
+	}
+	z.bytes += int64(sign) * n
+}
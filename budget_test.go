@@ -0,0 +1,159 @@
+package toolsearch
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/jonwraymond/toolindex"
+	"github.com/jonwraymond/toolmodel"
+)
+
+// fullIndexBytes returns the estimated index size of docs without a budget.
+func fullIndexBytes(t *testing.T, n int) int64 {
+	t.Helper()
+	s := NewBM25Searcher(BM25Config{})
+	defer func() { _ = s.Close() }()
+	if _, err := s.Search("tool", 1, makeTestDocs(n)); err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	return s.Stats().ApproxBytes
+}
+
+func TestStats(t *testing.T) {
+	s := NewBM25Searcher(BM25Config{})
+	defer func() { _ = s.Close() }()
+	if st := s.Stats(); st != (IndexStats{}) {
+		t.Errorf("Stats before build = %+v, want zero", st)
+	}
+
+	if _, err := s.Search("tool", 5, makeTestDocs(10)); err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	small := s.Stats()
+	if small.Docs != 10 || small.Terms == 0 || small.ApproxBytes <= 0 || small.BuildDuration <= 0 {
+		t.Errorf("Stats = %+v, want 10 docs and non-zero sizes", small)
+	}
+	if again := s.Stats(); again != small {
+		t.Errorf("Stats changed without a rebuild: %+v, then %+v", small, again)
+	}
+
+	if _, err := s.Search("tool", 5, makeTestDocs(20)); err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	if large := s.Stats(); large.Docs != 20 || large.Terms <= small.Terms || large.ApproxBytes <= small.ApproxBytes {
+		t.Errorf("Stats after growth = %+v, want more than %+v", large, small)
+	}
+}
+
+func TestMaxIndexBytes_Reject(t *testing.T) {
+	limit := fullIndexBytes(t, 20) / 2
+	s := NewBM25Searcher(BM25Config{MaxIndexBytes: limit})
+	defer func() { _ = s.Close() }()
+
+	if _, err := s.Search("tool", 5, makeTestDocs(5)); err != nil {
+		t.Fatalf("search within budget error: %v", err)
+	}
+
+	_, err := s.Search("tool", 5, makeTestDocs(20))
+	var budgetErr *IndexBudgetError
+	if !errors.As(err, &budgetErr) || !errors.Is(err, ErrIndexBudget) {
+		t.Fatalf("error = %v, want *IndexBudgetError", err)
+	}
+	if budgetErr.Docs != 20 || budgetErr.Limit != limit || budgetErr.Estimated <= limit {
+		t.Errorf("budget error = %+v", budgetErr)
+	}
+	if _, err := s.Search("", 5, makeTestDocs(20)); !errors.Is(err, ErrIndexBudget) {
+		t.Errorf("empty query error = %v, want ErrIndexBudget", err)
+	}
+	if st := s.Stats(); st.Docs != 5 {
+		t.Errorf("Stats.Docs = %d, want the previous index kept", st.Docs)
+	}
+}
+
+func TestMaxIndexBytes_Truncate(t *testing.T) {
+	limit := fullIndexBytes(t, 20) / 2
+	s := NewBM25Searcher(BM25Config{MaxIndexBytes: limit, BudgetMode: BudgetTruncate})
+	defer func() { _ = s.Close() }()
+
+	results, err := s.Search("", 20, makeTestDocs(20))
+	if err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	if len(results) == 0 || len(results) >= 20 {
+		t.Fatalf("got %d docs, want a truncated prefix", len(results))
+	}
+	sorted := sortDocsByID(makeTestDocs(20))
+	for i, r := range results {
+		if r.ID != sorted[i].ID {
+			t.Fatalf("result %d = %s, want the first IDs in order", i, r.ID)
+		}
+	}
+
+	if _, err := s.Search("tool", 20, makeTestDocs(20)); err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	if st := s.Stats(); st.Docs != len(results) || st.ApproxBytes > limit {
+		t.Errorf("Stats = %+v, want %d docs within %d bytes", st, len(results), limit)
+	}
+}
+
+func TestMaxIndexBytes_LiveUpdateOverBudget(t *testing.T) {
+	status := liveTool("status", "git", "Show the working tree status")
+	sizer := NewBM25Searcher(BM25Config{})
+	defer func() { _ = sizer.Close() }()
+	if _, err := sizer.Search("status", 1, []toolindex.SearchDoc{SearchDocFromTool(status)}); err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+
+	live, idx := newLiveIndex(t, BM25Config{MaxIndexBytes: sizer.Stats().ApproxBytes})
+	register(t, idx, status)
+	live.Follow(IndexSource(idx))
+	searchIDs(t, idx, "status")
+
+	register(t, idx, liveTool("diff", "git", "Show changes between commits"))
+	if live.UpdateCount() != 0 {
+		t.Errorf("UpdateCount = %d, want the update refused", live.UpdateCount())
+	}
+	if _, err := idx.Search("show", 10); !errors.Is(err, ErrIndexBudget) {
+		t.Errorf("search error = %v, want ErrIndexBudget after falling back", err)
+	}
+}
+
+func TestMaxIndexBytes_LiveUpdatesAdjustSize(t *testing.T) {
+	live, idx := newLiveIndex(t, BM25Config{MaxIndexBytes: 1 << 20})
+	register(t, idx,
+		liveTool("status", "git", "Show the working tree status"),
+		liveTool("commit", "git", "Record changes to the repository"),
+	)
+	live.Follow(IndexSource(idx))
+	searchIDs(t, idx, "status")
+
+	tagged := liveTool("commit", "git", "Record changes to the repository")
+	tagged.Tags = []string{"history"}
+	register(t, idx, liveTool("diff", "git", "Show changes between commits"), tagged)
+	if err := idx.UnregisterBackend("git:status", toolmodel.BackendKindMCP, "srv"); err != nil {
+		t.Fatalf("UnregisterBackend error: %v", err)
+	}
+	if live.UpdateCount() != 3 {
+		t.Fatalf("UpdateCount = %d, want 3", live.UpdateCount())
+	}
+
+	// The running estimate matches measuring the updated catalog afresh.
+	live.bm.mu.RLock()
+	size := live.bm.size
+	live.bm.mu.RUnlock()
+	st := live.Stats()
+	if size == nil || size.bytes != st.ApproxBytes || len(size.terms) != st.Terms {
+		t.Errorf("running size = %+v, want %d bytes, %d terms", size, st.ApproxBytes, st.Terms)
+	}
+}
+
+func TestRestore_OverBudget(t *testing.T) {
+	data := snapshotOf(t, BM25Config{}, makeTestDocs(20))
+
+	s := NewBM25Searcher(BM25Config{MaxIndexBytes: 100})
+	if err := s.Restore(bytes.NewReader(data)); !errors.Is(err, ErrIndexBudget) {
+		t.Errorf("Restore error = %v, want ErrIndexBudget", err)
+	}
+}
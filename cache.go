@@ -0,0 +1,130 @@
+package toolsearch
+
+import (
+	"container/list"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CacheStats reports query result cache activity.
+type CacheStats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"` // entries dropped for size or TTL
+	Entries   int    `json:"entries"`
+}
+
+// queryCache is an LRU cache of search results with an optional TTL.
+// Keys include the index fingerprint, so entries never outlive the index
+// they were computed against; purge drops them eagerly on rebuild.
+type queryCache struct {
+	size int
+	ttl  time.Duration
+	now  func() time.Time
+
+	mu      sync.Mutex
+	order   *list.List // front = most recently used
+	entries map[string]*list.Element
+	stats   CacheStats
+}
+
+type cacheEntry struct {
+	key     string
+	hits    []Hit
+	expires time.Time
+}
+
+func newQueryCache(size int, ttl time.Duration) *queryCache {
+	return &queryCache{
+		size:    size,
+		ttl:     ttl,
+		now:     time.Now,
+		order:   list.New(),
+		entries: make(map[string]*list.Element, size),
+	}
+}
+
+// cacheKey identifies a search by fingerprint, normalized query, effective
+// limit and options.
+func cacheKey(fingerprint, query string, limit int, opts SearchOptions) string {
+	var sb strings.Builder
+	sb.WriteString(fingerprint)
+	sb.WriteByte(0)
+	sb.WriteString(normalizeQuery(query))
+	sb.WriteByte(0)
+	sb.WriteString(strconv.Itoa(limit))
+	sb.WriteByte(0)
+	sb.WriteString(strconv.FormatBool(opts.Explain))
+	sb.WriteByte(0)
+	sb.WriteString(strconv.FormatBool(opts.Collapse))
+	sb.WriteByte(0)
+	sb.WriteString(opts.Visibility.key())
+	return sb.String()
+}
+
+// get returns a copy of the cached hits for key.
+func (c *queryCache) get(key string) ([]Hit, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+	entry := el.Value.(*cacheEntry)
+	if c.ttl > 0 && !c.now().Before(entry.expires) {
+		c.remove(el)
+		c.stats.Evictions++
+		c.stats.Misses++
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	c.stats.Hits++
+	return append([]Hit(nil), entry.hits...), true
+}
+
+// put stores a copy of hits under key, evicting the least recently used
+// entry when the cache is full.
+func (c *queryCache) put(key string, hits []Hit) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &cacheEntry{key: key, hits: append([]Hit(nil), hits...)}
+	if c.ttl > 0 {
+		entry.expires = c.now().Add(c.ttl)
+	}
+	if el, ok := c.entries[key]; ok {
+		el.Value = entry
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+		c.stats.Evictions++
+	}
+}
+
+// purge drops every entry.
+func (c *queryCache) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	clear(c.entries)
+}
+
+func (c *queryCache) remove(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.entries, el.Value.(*cacheEntry).key)
+}
+
+func (c *queryCache) snapshot() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st := c.stats
+	st.Entries = c.order.Len()
+	return st
+}
@@ -0,0 +1,140 @@
+package toolsearch
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jonwraymond/toolindex"
+)
+
+func TestCache_DisabledByDefault(t *testing.T) {
+	s := NewBM25Searcher(BM25Config{})
+	docs := makeTestDocs(5)
+
+	for range 2 {
+		if _, err := s.Search("tool", 3, docs); err != nil {
+			t.Fatalf("search error: %v", err)
+		}
+	}
+	if st := s.CacheStats(); st != (CacheStats{}) {
+		t.Errorf("expected zero stats without a cache, got %+v", st)
+	}
+}
+
+func TestCache_HitsOnNormalizedQuery(t *testing.T) {
+	s := NewBM25Searcher(BM25Config{CacheSize: 8})
+	docs := makeTestDocs(5)
+
+	first, err := s.Search("tool description", 3, docs)
+	if err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	second, err := s.Search("  Tool   DESCRIPTION ", 3, docs)
+	if err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	if !slices.EqualFunc(first, second, func(a, b toolindex.Summary) bool { return a.ID == b.ID }) {
+		t.Errorf("cached results %v differ from %v", second, first)
+	}
+
+	st := s.CacheStats()
+	if st.Hits != 1 || st.Misses != 1 || st.Entries != 1 {
+		t.Errorf("unexpected stats: %+v", st)
+	}
+
+	// A different limit or options is a different entry.
+	if _, err := s.Search("tool description", 2, docs); err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	if _, err := s.SearchWithOptions("tool description", 3, docs, SearchOptions{Explain: true}); err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	if st := s.CacheStats(); st.Misses != 3 || st.Entries != 3 {
+		t.Errorf("expected distinct entries per limit and options, got %+v", st)
+	}
+}
+
+func TestCache_ResultsAreCopies(t *testing.T) {
+	s := NewBM25Searcher(BM25Config{CacheSize: 8})
+	docs := makeTestDocs(3)
+
+	first, err := s.Search("tool", 3, docs)
+	if err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	first[0].ID = "mutated"
+
+	second, err := s.Search("tool", 3, docs)
+	if err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	if second[0].ID == "mutated" {
+		t.Error("mutating a result changed the cached entry")
+	}
+}
+
+func TestCache_InvalidatedOnRebuild(t *testing.T) {
+	s := NewBM25Searcher(BM25Config{CacheSize: 8})
+	docs := makeTestDocs(3)
+
+	if _, err := s.Search("tool", 3, docs); err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	docs = append(docs, toolindex.SearchDoc{ID: "extra", DocText: "tool extra", Summary: toolindex.Summary{ID: "extra"}})
+	results, err := s.Search("tool", 4, docs)
+	if err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	if len(results) != 4 {
+		t.Errorf("got %d results after catalog change, want 4", len(results))
+	}
+	if st := s.CacheStats(); st.Hits != 0 || st.Entries != 1 {
+		t.Errorf("expected rebuild to drop stale entries, got %+v", st)
+	}
+}
+
+func TestCache_SizeLimitEvictsLeastRecentlyUsed(t *testing.T) {
+	s := NewBM25Searcher(BM25Config{CacheSize: 2})
+	docs := makeTestDocs(5)
+
+	for _, q := range []string{"tool", "description", "tool", "test"} {
+		if _, err := s.Search(q, 3, docs); err != nil {
+			t.Fatalf("search error: %v", err)
+		}
+	}
+	// "description" was least recently used when "test" was added.
+	if _, err := s.Search("tool", 3, docs); err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	if _, err := s.Search("description", 3, docs); err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+
+	st := s.CacheStats()
+	if st.Hits != 2 || st.Misses != 4 || st.Entries != 2 || st.Evictions != 2 {
+		t.Errorf("unexpected stats: %+v", st)
+	}
+}
+
+func TestCache_TTL(t *testing.T) {
+	s := NewBM25Searcher(BM25Config{CacheSize: 8, CacheTTL: time.Minute})
+	now := time.Unix(0, 0)
+	s.cache.now = func() time.Time { return now }
+	docs := makeTestDocs(3)
+
+	for range 2 {
+		if _, err := s.Search("tool", 3, docs); err != nil {
+			t.Fatalf("search error: %v", err)
+		}
+	}
+	now = now.Add(time.Minute)
+	if _, err := s.Search("tool", 3, docs); err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+
+	st := s.CacheStats()
+	if st.Hits != 1 || st.Misses != 2 || st.Evictions != 1 {
+		t.Errorf("unexpected stats: %+v", st)
+	}
+}
@@ -0,0 +1,107 @@
+// Command toolsearch-mcp serves a tool catalog over MCP stdio.
+//
+// It loads toolmodel.Tool definitions from a JSON or JSONL catalog,
+// registers them in a toolindex backed by a BM25 searcher, and exposes
+// search_tools, describe_tool and list_namespaces to MCP clients.
+//
+// Usage:
+//
+//	toolsearch-mcp -catalog tools.jsonl [-backend NAME] [flags]
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/jonwraymond/toolindex"
+	"github.com/jonwraymond/toolmodel"
+	"github.com/jonwraymond/toolsearch"
+	"github.com/jonwraymond/toolsearch/internal/catalog"
+	"github.com/jonwraymond/toolsearch/mcpserver"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("toolsearch-mcp: ")
+
+	var (
+		catalogPath = flag.String("catalog", "", "catalog file of toolmodel.Tool entries (JSON or JSONL)")
+		backendName = flag.String("backend", "catalog", "MCP server name recorded as each tool's backend")
+		maxLimit    = flag.Int("max-limit", 50, "maximum results per search_tools call")
+		cfg         toolsearch.BM25Config
+	)
+	flag.IntVar(&cfg.NameBoost, "name-boost", 0, "name boost (0 = default)")
+	flag.IntVar(&cfg.NamespaceBoost, "namespace-boost", 0, "namespace boost (0 = default)")
+	flag.IntVar(&cfg.TagsBoost, "tags-boost", 0, "tags boost (0 = default)")
+	flag.IntVar(&cfg.MaxDocs, "max-docs", 0, "maximum documents to index (0 = unlimited)")
+	flag.IntVar(&cfg.MaxDocTextLen, "max-doctext-len", 0, "DocText truncation length (0 = unlimited)")
+	flag.Parse()
+
+	if *catalogPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(*catalogPath, *backendName, *maxLimit, cfg); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run serves the catalog at catalogPath until the client disconnects or
+// the process is signaled. It closes the searcher before returning, so
+// main exits on errors only after cleanup.
+func run(catalogPath, backendName string, maxLimit int, cfg toolsearch.BM25Config) error {
+	searcher := toolsearch.NewBM25Searcher(cfg)
+	defer func() { _ = searcher.Close() }()
+
+	idx, err := loadIndex(catalogPath, os.Stdin, backendName, searcher)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	srv := mcpserver.New(idx, mcpserver.Options{Name: "toolsearch-mcp", MaxLimit: maxLimit})
+	// The client closing stdin ends the session normally.
+	err = srv.Run(ctx, &mcp.StdioTransport{})
+	if err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// loadIndex registers every tool in the catalog at path under an MCP
+// backend named backendName.
+func loadIndex(path string, stdin io.Reader, backendName string, searcher toolindex.Searcher) (*toolindex.InMemoryIndex, error) {
+	if path == "-" {
+		return nil, errors.New("-catalog - is not supported: stdin carries the MCP session")
+	}
+	c, err := catalog.Load(path, stdin)
+	if err != nil {
+		return nil, err
+	}
+	if len(c.Tools) != len(c.Docs) {
+		return nil, fmt.Errorf("%s: catalog must contain toolmodel.Tool entries, found %d search docs", path, len(c.Docs)-len(c.Tools))
+	}
+
+	idx := toolindex.NewInMemoryIndex(toolindex.IndexOptions{Searcher: searcher})
+	backend := toolmodel.ToolBackend{
+		Kind: toolmodel.BackendKindMCP,
+		MCP:  &toolmodel.MCPBackend{ServerName: backendName},
+	}
+	for _, tool := range c.Tools {
+		if err := idx.RegisterTool(tool, backend); err != nil {
+			return nil, fmt.Errorf("register %s: %w", tool.ToolID(), err)
+		}
+	}
+	return idx, nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jonwraymond/toolsearch"
+)
+
+func TestLoadIndex(t *testing.T) {
+	idx, err := loadIndex("../../testdata/toolindex_integration.jsonl", nil, "catalog", toolsearch.NewBM25Searcher(toolsearch.BM25Config{}))
+	if err != nil {
+		t.Fatalf("loadIndex error: %v", err)
+	}
+	namespaces, err := idx.ListNamespaces()
+	if err != nil {
+		t.Fatalf("ListNamespaces error: %v", err)
+	}
+	if len(namespaces) != 3 {
+		t.Errorf("got namespaces %v, want 3", namespaces)
+	}
+	results, err := idx.Search("containers", 1)
+	if err != nil {
+		t.Fatalf("Search error: %v", err)
+	}
+	if len(results) != 1 || !strings.HasPrefix(results[0].ID, "docker:") {
+		t.Errorf("unexpected results: %v", results)
+	}
+}
+
+func TestLoadIndex_RejectsSearchDocs(t *testing.T) {
+	_, err := loadIndex("../../testdata/golden/catalog.json", nil, "catalog", toolsearch.NewBM25Searcher(toolsearch.BM25Config{}))
+	if err == nil || !strings.Contains(err.Error(), "toolmodel.Tool entries") {
+		t.Errorf("expected search doc rejection, got %v", err)
+	}
+}
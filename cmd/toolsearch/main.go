@@ -0,0 +1,523 @@
+// Command toolsearch queries and inspects a tool catalog with BM25 ranking.
+//
+// Usage:
+//
+//	toolsearch <command> -catalog FILE [flags] [query]
+//	toolsearch report -log FILE [flags]
+//
+// Commands:
+//
+//	query    rank tools for a query
+//	explain  show how each result's score was computed
+//	facets   count namespaces and tags of the tools matching a query
+//	stats    summarize the catalog
+//	snapshot build the index and write it to a file for Restore
+//	report   rank zero-result, low-score and re-searched queries of a query log
+//	lint     check the catalog for tools that will rank poorly
+//
+// The catalog is a JSON array or JSONL file of toolmodel.Tool or
+// toolindex.SearchDoc entries; "-" reads stdin. Output is an aligned table
+// by default, or JSON with -format json.
+//
+// Examples:
+//
+//	toolsearch query -catalog tools.jsonl create issue
+//	toolsearch explain -catalog tools.jsonl -id github:create_issue issue
+//	toolsearch facets -catalog tools.jsonl -format json
+//	toolsearch snapshot -catalog tools.jsonl -o index.snap
+//	toolsearch report -log queries.jsonl -window 1m
+//	toolsearch lint -catalog tools.jsonl -max-doctext-len 5000 -format json
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jonwraymond/toolindex"
+	"github.com/jonwraymond/toolsearch"
+	"github.com/jonwraymond/toolsearch/analytics"
+	"github.com/jonwraymond/toolsearch/internal/catalog"
+	"github.com/jonwraymond/toolsearch/lint"
+)
+
+const usage = `usage: toolsearch <command> -catalog FILE [flags] [query]
+       toolsearch report -log FILE [flags]
+
+commands:
+  query    rank tools for a query
+  explain  show how each result's score was computed
+  facets   count namespaces and tags of the tools matching a query
+  stats    summarize the catalog
+  snapshot build the index and write it to a file for Restore
+  report   rank zero-result, low-score and re-searched queries of a query log
+  lint     check the catalog for tools that will rank poorly
+
+run "toolsearch <command> -h" for command flags
+`
+
+// errUsage signals that usage has already been reported.
+var errUsage = errors.New("usage")
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes the command line and returns the process exit code.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	commands := map[string]func(*env) error{
+		"query":    runQuery,
+		"explain":  runExplain,
+		"facets":   runFacets,
+		"stats":    runStats,
+		"snapshot": runSnapshot,
+		"report":   runReport,
+		"lint":     runLint,
+	}
+	name := args[0]
+	cmd, ok := commands[name]
+	if !ok {
+		if name == "-h" || name == "-help" || name == "help" {
+			fmt.Fprint(stdout, usage)
+			return 0
+		}
+		fmt.Fprintf(stderr, "toolsearch: unknown command %q\n\n%s", name, usage)
+		return 2
+	}
+
+	e := &env{stdin: stdin, stdout: stdout, stderr: stderr}
+	if err := e.parse(name, args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if err := cmd(e); err != nil {
+		if errors.Is(err, errUsage) {
+			return 2
+		}
+		fmt.Fprintf(stderr, "toolsearch %s: %v\n", name, err)
+		return 1
+	}
+	return 0
+}
+
+// env carries parsed flags and I/O for a command.
+type env struct {
+	stdin          io.Reader
+	stdout, stderr io.Writer
+
+	flags   *flag.FlagSet
+	catalog string
+	format  string
+	limit   int
+	id      string
+	output  string
+	cfg     toolsearch.BM25Config
+	opts    toolsearch.SearchOptions
+	budget  toolsearch.TokenBudget
+	log     string
+	report  analytics.Options
+	failOn  string
+	disable string
+
+	query string
+	docs  []toolindex.SearchDoc
+}
+
+func (e *env) parse(name string, args []string) error {
+	fs := flag.NewFlagSet("toolsearch "+name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.StringVar(&e.catalog, "catalog", "", "catalog file (JSON or JSONL); \"-\" reads stdin")
+	fs.StringVar(&e.format, "format", "table", "output format: table or json")
+	fs.IntVar(&e.limit, "limit", 10, "maximum number of results, facet values or report queries")
+	fs.IntVar(&e.cfg.NameBoost, "name-boost", 0, "name boost (0 = default)")
+	fs.IntVar(&e.cfg.NamespaceBoost, "namespace-boost", 0, "namespace boost (0 = default)")
+	fs.IntVar(&e.cfg.TagsBoost, "tags-boost", 0, "tags boost (0 = default)")
+	fs.IntVar(&e.cfg.MaxDocs, "max-docs", 0, "maximum documents to index (0 = unlimited)")
+	fs.IntVar(&e.cfg.MaxDocTextLen, "max-doctext-len", 0, "DocText truncation length (0 = unlimited)")
+	fs.Int64Var(&e.cfg.MaxIndexBytes, "max-index-bytes", 0, "index memory budget; larger catalogs fail (0 = unlimited)")
+	fs.IntVar(&e.cfg.Shards, "shards", 0, "split the index into this many shards built in parallel (0 = one index)")
+	if name == "query" {
+		fs.BoolVar(&e.opts.Collapse, "collapse", false, "list near-duplicate tools as alternates of the best one")
+		fs.Float64Var(&e.cfg.DuplicateThreshold, "duplicate-threshold", 0, "similarity at which tools are near duplicates (0 = default)")
+		fs.Float64Var(&e.cfg.Diversity.Lambda, "mmr-lambda", 0, "rerank by maximal marginal relevance; lower spreads more namespaces (0 = off)")
+		fs.IntVar(&e.cfg.Diversity.MaxPerNamespace, "max-per-namespace", 0, "rank at most this many tools per namespace ahead of others (0 = no cap)")
+		fs.IntVar(&e.budget.MaxTokens, "max-tokens", 0, "return only the results whose summaries fit in this many tokens (0 = no budget)")
+		fs.BoolVar(&e.budget.TrimDescriptions, "trim", false, "with -max-tokens, shorten the description of the first result that does not fit")
+	}
+	if name == "explain" {
+		fs.StringVar(&e.id, "id", "", "only explain the result with this tool ID")
+	}
+	if name == "snapshot" {
+		fs.StringVar(&e.output, "o", "", "snapshot output file; \"-\" writes stdout")
+	}
+	if name == "report" {
+		fs.StringVar(&e.log, "log", "", "query log file (JSONL); \"-\" reads stdin")
+		fs.Float64Var(&e.report.LowScore, "low-score", 0, "top score below which a search is low-score (0 = lowest tenth of the log)")
+		fs.DurationVar(&e.report.Window, "window", 30*time.Second, "how soon a different query in a session counts as a re-search")
+	}
+	if name == "lint" {
+		fs.StringVar(&e.failOn, "fail-on", "error", "exit 1 on findings of this severity or worse: error, warning or none")
+		fs.StringVar(&e.disable, "disable", "", "comma-separated lint rules to skip")
+	}
+	e.flags = fs
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	switch {
+	case name == "report":
+		if e.log == "" {
+			return e.usageError("-log is required")
+		}
+	case e.catalog == "":
+		return e.usageError("-catalog is required")
+	}
+	if name == "lint" && e.failOn != "error" && e.failOn != "warning" && e.failOn != "none" {
+		return e.usageError("-fail-on must be error, warning or none")
+	}
+	if e.format != "table" && e.format != "json" {
+		return e.usageError("-format must be table or json")
+	}
+	e.query = strings.Join(fs.Args(), " ")
+	return nil
+}
+
+func (e *env) usageError(msg string) error {
+	fmt.Fprintf(e.stderr, "%s: %s\n", e.flags.Name(), msg)
+	e.flags.Usage()
+	return errUsage
+}
+
+func (e *env) load() error {
+	c, err := catalog.Load(e.catalog, e.stdin)
+	if err != nil {
+		return err
+	}
+	e.docs = c.Docs
+	return nil
+}
+
+func (e *env) writeJSON(v any) error {
+	enc := json.NewEncoder(e.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// search runs the query against the loaded catalog.
+func (e *env) search(limit int, explain bool) (toolsearch.SearchResult, error) {
+	s := toolsearch.NewBM25Searcher(e.cfg)
+	defer func() { _ = s.Close() }()
+	opts := e.opts
+	opts.Explain = explain
+	if e.budget.MaxTokens > 0 {
+		opts.Budget = &e.budget
+	}
+	return s.SearchWithOptions(e.query, limit, e.docs, opts)
+}
+
+func runQuery(e *env) error {
+	if e.query == "" {
+		return e.usageError("query text is required")
+	}
+	if err := e.load(); err != nil {
+		return err
+	}
+	result, err := e.search(e.limit, false)
+	if err != nil {
+		return err
+	}
+	if e.format == "json" {
+		return e.writeJSON(result)
+	}
+
+	if len(result.Hits) == 0 {
+		_, err := fmt.Fprintln(e.stdout, "no results")
+		return err
+	}
+	tw := tabwriter.NewWriter(e.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RANK\tSCORE\tID\tDESCRIPTION")
+	for i, hit := range result.Hits {
+		desc := hit.Summary.ShortDescription
+		if len(hit.Alternates) > 0 {
+			alternates := make([]string, len(hit.Alternates))
+			for j, alt := range hit.Alternates {
+				alternates[j] = alt.ID
+			}
+			desc += " (also " + strings.Join(alternates, ", ") + ")"
+		}
+		fmt.Fprintf(tw, "%d\t%.4f\t%s\t%s\n", i+1, hit.Score, hit.Summary.ID, desc)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if e.budget.MaxTokens > 0 {
+		_, err = fmt.Fprintf(e.stdout, "%d tokens, %d results dropped\n", result.TokensUsed, result.Dropped)
+	}
+	return err
+}
+
+func runExplain(e *env) error {
+	if e.query == "" {
+		return e.usageError("query text is required")
+	}
+	if err := e.load(); err != nil {
+		return err
+	}
+	limit := e.limit
+	if e.id != "" {
+		limit = len(e.docs)
+	}
+	result, err := e.search(limit, true)
+	if err != nil {
+		return err
+	}
+
+	hits := result.Hits
+	rank := 0
+	if e.id != "" {
+		hits = nil
+		for i, hit := range result.Hits {
+			if hit.Summary.ID == e.id {
+				hits = []toolsearch.Hit{hit}
+				rank = i
+				break
+			}
+		}
+		if hits == nil {
+			return fmt.Errorf("tool %q does not match query %q", e.id, e.query)
+		}
+	}
+	if e.format == "json" {
+		return e.writeJSON(toolsearch.SearchResult{Hits: hits})
+	}
+
+	var sb strings.Builder
+	for i, hit := range hits {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, "%d. %s  score %.4f\n", rank+i+1, hit.Summary.ID, hit.Score)
+		writeExplanation(&sb, hit.Explanation, 1)
+	}
+	if len(hits) == 0 {
+		sb.WriteString("no results\n")
+	}
+	_, err = io.WriteString(e.stdout, sb.String())
+	return err
+}
+
+func writeExplanation(sb *strings.Builder, expl *toolsearch.Explanation, depth int) {
+	if expl == nil {
+		return
+	}
+	fmt.Fprintf(sb, "%s%.4f  %s\n", strings.Repeat("  ", depth), expl.Value, expl.Message)
+	for _, child := range expl.Children {
+		writeExplanation(sb, child, depth+1)
+	}
+}
+
+func runFacets(e *env) error {
+	if err := e.load(); err != nil {
+		return err
+	}
+	result, err := e.search(len(e.docs), false)
+	if err != nil {
+		return err
+	}
+	facets := toolsearch.ComputeFacets(result.Summaries())
+	if e.limit > 0 {
+		facets.Namespaces = facets.Namespaces[:min(e.limit, len(facets.Namespaces))]
+		facets.Tags = facets.Tags[:min(e.limit, len(facets.Tags))]
+	}
+	if e.format == "json" {
+		return e.writeJSON(facets)
+	}
+
+	tw := tabwriter.NewWriter(e.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAMESPACE\tCOUNT")
+	for _, f := range facets.Namespaces {
+		fmt.Fprintf(tw, "%s\t%d\n", f.Value, f.Count)
+	}
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "TAG\tCOUNT")
+	for _, f := range facets.Tags {
+		fmt.Fprintf(tw, "%s\t%d\n", f.Value, f.Count)
+	}
+	return tw.Flush()
+}
+
+// catalogStats summarizes a loaded catalog.
+type catalogStats struct {
+	Documents         int `json:"documents"`
+	Namespaces        int `json:"namespaces"`
+	Tags              int `json:"tags"`
+	EmptyDescriptions int `json:"empty_descriptions"`
+	AvgDocTextBytes   int `json:"avg_doctext_bytes"`
+	MaxDocTextBytes   int `json:"max_doctext_bytes"`
+	TruncatedDocTexts int `json:"truncated_doctexts"`
+	ExcludedByMaxDocs int `json:"excluded_by_max_docs"`
+
+	IndexTerms       int     `json:"index_terms"`
+	IndexApproxBytes int64   `json:"index_approx_bytes"`
+	IndexBuildMillis float64 `json:"index_build_ms"`
+}
+
+func runStats(e *env) error {
+	if err := e.load(); err != nil {
+		return err
+	}
+
+	var st catalogStats
+	summaries := make([]toolindex.Summary, len(e.docs))
+	total := 0
+	for i, doc := range e.docs {
+		summaries[i] = doc.Summary
+		n := len(doc.DocText)
+		total += n
+		st.MaxDocTextBytes = max(st.MaxDocTextBytes, n)
+		if e.cfg.MaxDocTextLen > 0 && n > e.cfg.MaxDocTextLen {
+			st.TruncatedDocTexts++
+		}
+		if strings.TrimSpace(doc.Summary.ShortDescription) == "" {
+			st.EmptyDescriptions++
+		}
+	}
+	facets := toolsearch.ComputeFacets(summaries)
+	st.Documents = len(e.docs)
+	st.Namespaces = len(facets.Namespaces)
+	st.Tags = len(facets.Tags)
+	if st.Documents > 0 {
+		st.AvgDocTextBytes = total / st.Documents
+	}
+	if e.cfg.MaxDocs > 0 && st.Documents > e.cfg.MaxDocs {
+		st.ExcludedByMaxDocs = st.Documents - e.cfg.MaxDocs
+	}
+
+	if len(e.docs) > 0 {
+		s := toolsearch.NewBM25Searcher(e.cfg)
+		defer func() { _ = s.Close() }()
+		// Any non-empty query builds the index.
+		if _, err := s.Search("stats", 1, e.docs); err != nil {
+			return err
+		}
+		idx := s.Stats()
+		st.IndexTerms = idx.Terms
+		st.IndexApproxBytes = idx.ApproxBytes
+		st.IndexBuildMillis = float64(idx.BuildDuration.Microseconds()) / 1000
+	}
+
+	if e.format == "json" {
+		return e.writeJSON(st)
+	}
+	tw := tabwriter.NewWriter(e.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "documents\t%d\n", st.Documents)
+	fmt.Fprintf(tw, "namespaces\t%d\n", st.Namespaces)
+	fmt.Fprintf(tw, "tags\t%d\n", st.Tags)
+	fmt.Fprintf(tw, "empty descriptions\t%d\n", st.EmptyDescriptions)
+	fmt.Fprintf(tw, "avg doctext bytes\t%d\n", st.AvgDocTextBytes)
+	fmt.Fprintf(tw, "max doctext bytes\t%d\n", st.MaxDocTextBytes)
+	fmt.Fprintf(tw, "truncated doctexts\t%d\n", st.TruncatedDocTexts)
+	fmt.Fprintf(tw, "excluded by max-docs\t%d\n", st.ExcludedByMaxDocs)
+	fmt.Fprintf(tw, "index terms\t%d\n", st.IndexTerms)
+	fmt.Fprintf(tw, "index approx bytes\t%d\n", st.IndexApproxBytes)
+	fmt.Fprintf(tw, "index build ms\t%.1f\n", st.IndexBuildMillis)
+	return tw.Flush()
+}
+
+func runSnapshot(e *env) error {
+	if e.output == "" {
+		return e.usageError("-o is required")
+	}
+	if err := e.load(); err != nil {
+		return err
+	}
+	if len(e.docs) == 0 {
+		return errors.New("catalog is empty")
+	}
+
+	s := toolsearch.NewBM25Searcher(e.cfg)
+	defer func() { _ = s.Close() }()
+	// Any non-empty query builds the index.
+	if _, err := s.Search("snapshot", 1, e.docs); err != nil {
+		return err
+	}
+	if e.output == "-" {
+		return s.Snapshot(e.stdout)
+	}
+
+	f, err := os.Create(e.output)
+	if err != nil {
+		return err
+	}
+	if err := s.Snapshot(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func runReport(e *env) error {
+	var r io.Reader = e.stdin
+	if e.log != "-" {
+		f, err := os.Open(e.log)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		r = f
+	}
+	records, err := toolsearch.ReadQueryLog(r)
+	if err != nil {
+		return err
+	}
+	e.report.Limit = e.limit
+	report := analytics.Analyze(records, e.report)
+	if e.format == "json" {
+		return e.writeJSON(report)
+	}
+	return report.WriteText(e.stdout)
+}
+
+func runLint(e *env) error {
+	if err := e.load(); err != nil {
+		return err
+	}
+	opts := lint.Options{MaxDocTextLen: e.cfg.MaxDocTextLen}
+	if e.disable != "" {
+		opts.Disable = strings.Split(e.disable, ",")
+	}
+	report := lint.Lint(e.docs, opts)
+	var err error
+	if e.format == "json" {
+		err = e.writeJSON(report)
+	} else {
+		err = report.WriteText(e.stdout)
+	}
+	if err != nil {
+		return err
+	}
+
+	failing := 0
+	switch e.failOn {
+	case "error":
+		failing = report.Errors
+	case "warning":
+		failing = report.Errors + report.Warnings
+	}
+	if failing > 0 {
+		return fmt.Errorf("%d findings at %s severity or worse", failing, e.failOn)
+	}
+	return nil
+}
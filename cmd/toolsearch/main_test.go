@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jonwraymond/toolsearch"
+	"github.com/jonwraymond/toolsearch/analytics"
+	"github.com/jonwraymond/toolsearch/lint"
+)
+
+const toolsCatalog = "../../testdata/toolindex_integration.jsonl"
+
+func runCLI(t *testing.T, stdin string, args ...string) (string, string, int) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return stdout.String(), stderr.String(), code
+}
+
+func TestRun_Usage(t *testing.T) {
+	_, stderr, code := runCLI(t, "")
+	if code != 2 || !strings.Contains(stderr, "usage: toolsearch") {
+		t.Errorf("code = %d, stderr = %q", code, stderr)
+	}
+
+	_, stderr, code = runCLI(t, "", "bogus")
+	if code != 2 || !strings.Contains(stderr, `unknown command "bogus"`) {
+		t.Errorf("code = %d, stderr = %q", code, stderr)
+	}
+
+	_, stderr, code = runCLI(t, "", "query", "git")
+	if code != 2 || !strings.Contains(stderr, "-catalog is required") {
+		t.Errorf("code = %d, stderr = %q", code, stderr)
+	}
+}
+
+func TestRun_QueryTable(t *testing.T) {
+	stdout, stderr, code := runCLI(t, "", "query", "-catalog", toolsCatalog, "-limit", "2", "containers")
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected header and 2 rows, got:\n%s", stdout)
+	}
+	if !strings.HasPrefix(lines[0], "RANK") || !strings.Contains(lines[1], "docker:") {
+		t.Errorf("unexpected table:\n%s", stdout)
+	}
+}
+
+func TestRun_QueryJSON_FromStdin(t *testing.T) {
+	doc := `{"ID":"a:b","DocText":"alpha tool","Summary":{"id":"a:b","name":"b","namespace":"a"}}`
+	stdout, stderr, code := runCLI(t, doc, "query", "-catalog", "-", "-format", "json", "alpha")
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	var result toolsearch.SearchResult
+	if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+		t.Fatalf("decode output: %v\n%s", err, stdout)
+	}
+	if len(result.Hits) != 1 || result.Hits[0].Summary.ID != "a:b" || result.Hits[0].Score <= 0 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
+
+func TestRun_ExplainSingleTool(t *testing.T) {
+	stdout, stderr, code := runCLI(t, "", "explain", "-catalog", toolsCatalog, "-id", "kubectl:kubectl_apply", "devops")
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	if !strings.Contains(stdout, "kubectl:kubectl_apply  score") || !strings.Contains(stdout, "idf(") {
+		t.Errorf("unexpected explanation:\n%s", stdout)
+	}
+	if strings.Contains(stdout, "docker:") {
+		t.Errorf("explain -id printed other tools:\n%s", stdout)
+	}
+
+	_, stderr, code = runCLI(t, "", "explain", "-catalog", toolsCatalog, "-id", "git:git_push", "devops")
+	if code != 1 || !strings.Contains(stderr, "does not match") {
+		t.Errorf("code = %d, stderr = %q", code, stderr)
+	}
+}
+
+func TestRun_Facets(t *testing.T) {
+	stdout, stderr, code := runCLI(t, "", "facets", "-catalog", toolsCatalog, "-format", "json", "devops")
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	var facets toolsearch.Facets
+	if err := json.Unmarshal([]byte(stdout), &facets); err != nil {
+		t.Fatalf("decode output: %v", err)
+	}
+	if len(facets.Namespaces) != 2 {
+		t.Fatalf("expected docker and kubectl namespaces, got %+v", facets.Namespaces)
+	}
+	if facets.Tags[0] != (toolsearch.FacetCount{Value: "devops", Count: 4}) {
+		t.Errorf("unexpected top tag: %+v", facets.Tags[0])
+	}
+}
+
+func TestRun_Stats(t *testing.T) {
+	stdout, stderr, code := runCLI(t, "", "stats", "-catalog", toolsCatalog, "-max-docs", "5")
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	for _, want := range []string{"documents", "7", "namespaces", "excluded by max-docs", "index terms", "index approx bytes"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("stats output missing %q:\n%s", want, stdout)
+		}
+	}
+}
+
+func TestRun_StatsOverBudget(t *testing.T) {
+	_, stderr, code := runCLI(t, "", "stats", "-catalog", toolsCatalog, "-max-index-bytes", "100")
+	if code != 1 || !strings.Contains(stderr, "index exceeds memory budget") {
+		t.Errorf("code = %d, stderr = %q", code, stderr)
+	}
+}
+
+func TestRun_Snapshot(t *testing.T) {
+	out := t.TempDir() + "/index.snap"
+	if _, stderr, code := runCLI(t, "", "snapshot", "-catalog", toolsCatalog, "-o", out); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	f, err := os.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	s := toolsearch.NewBM25Searcher(toolsearch.BM25Config{})
+	if err := s.Restore(f); err != nil {
+		t.Errorf("Restore error: %v", err)
+	}
+
+	if _, stderr, code := runCLI(t, "", "snapshot", "-catalog", toolsCatalog); code != 2 || !strings.Contains(stderr, "-o is required") {
+		t.Errorf("code = %d, stderr = %q", code, stderr)
+	}
+}
+
+func TestRun_Report(t *testing.T) {
+	log := `{"time":"2026-03-01T12:00:00Z","session":"s1","query":"rotate secrets","normalized":"rotate secrets","shown":[]}
+{"time":"2026-03-01T12:00:05Z","session":"s1","query":"vault rotate","normalized":"vault rotate","shown":["vault:rotate"],"scores":[2.5]}
+`
+	stdout, stderr, code := runCLI(t, log, "report", "-log", "-")
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	for _, want := range []string{"zero results: 1 searches (50.0%)", "re-searched within 30s: 1 searches", "vault rotate"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("report missing %q:\n%s", want, stdout)
+		}
+	}
+
+	stdout, stderr, code = runCLI(t, log, "report", "-log", "-", "-window", "1s", "-format", "json")
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	var report analytics.Report
+	if err := json.Unmarshal([]byte(stdout), &report); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	if report.ZeroResult.Searches != 1 || report.Researched.Searches != 0 {
+		t.Errorf("report = %+v", report)
+	}
+
+	if _, stderr, code := runCLI(t, "", "report"); code != 2 || !strings.Contains(stderr, "-log is required") {
+		t.Errorf("code = %d, stderr = %q", code, stderr)
+	}
+}
+
+func TestRun_Lint(t *testing.T) {
+	stdout, stderr, code := runCLI(t, "", "lint", "-catalog", toolsCatalog)
+	if code != 0 || !strings.Contains(stdout, "7 tools: 0 errors") {
+		t.Errorf("exit %d, stdout:\n%s\nstderr: %s", code, stdout, stderr)
+	}
+
+	catalog := `{"name": "run", "description": "", "inputSchema": {"type": "object"}, "namespace": "ops"}
+{"name": "deploy", "description": "Deploy a service to production", "inputSchema": {"type": "object"}, "namespace": "ops"}
+`
+	stdout, stderr, code = runCLI(t, catalog, "lint", "-catalog", "-", "-format", "json")
+	if code != 1 || !strings.Contains(stderr, "1 findings at error severity or worse") {
+		t.Errorf("code = %d, stderr = %q", code, stderr)
+	}
+	var report lint.Report
+	if err := json.Unmarshal([]byte(stdout), &report); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, stdout)
+	}
+	if report.Errors != 1 || report.Findings[0].ID != "ops:run" {
+		t.Errorf("report = %+v", report)
+	}
+
+	if _, _, code := runCLI(t, catalog, "lint", "-catalog", "-", "-fail-on", "none"); code != 0 {
+		t.Errorf("-fail-on none: code = %d", code)
+	}
+	if _, _, code := runCLI(t, catalog, "lint", "-catalog", "-", "-disable", "missing-description,generic-name", "-fail-on", "warning"); code != 0 {
+		t.Errorf("-disable: code = %d", code)
+	}
+}
+
+func TestRun_QueryCollapse(t *testing.T) {
+	catalog := `{"name": "create_issue", "description": "Create an issue in a repository", "inputSchema": {"type": "object"}, "namespace": "github"}
+{"name": "issue_create", "description": "Create a new issue in a repository", "inputSchema": {"type": "object"}, "namespace": "gh"}
+{"name": "create_ticket", "description": "Create a ticket in a project backlog", "inputSchema": {"type": "object"}, "namespace": "jira"}
+`
+	stdout, stderr, code := runCLI(t, catalog, "query", "-catalog", "-", "-collapse", "create", "issue")
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[1], "github:create_issue") || !strings.Contains(lines[1], "(also gh:issue_create)") {
+		t.Errorf("unexpected table:\n%s", stdout)
+	}
+}
+
+func TestRun_QueryDiversity(t *testing.T) {
+	stdout, stderr, code := runCLI(t, "", "query", "-catalog", toolsCatalog, "-limit", "2", "-max-per-namespace", "1", "devops")
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[1], "docker:") || !strings.Contains(lines[2], "kubectl:") {
+		t.Errorf("unexpected table:\n%s", stdout)
+	}
+}
+
+func TestRun_QueryTokenBudget(t *testing.T) {
+	stdout, stderr, code := runCLI(t, "", "query", "-catalog", toolsCatalog, "-max-tokens", "150", "devops")
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 4 || !strings.HasSuffix(lines[3], "tokens, 2 results dropped") {
+		t.Errorf("unexpected output:\n%s", stdout)
+	}
+}
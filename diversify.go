@@ -0,0 +1,104 @@
+package toolsearch
+
+// Diversity reorders results so the top of a ranking spans namespaces
+// instead of filling up with one server's tools. The zero value keeps
+// BM25 order. Reranking considers the best 5×limit matches and is
+// deterministic; hits keep their BM25 scores, so scores need not
+// decrease down a diversified ranking. Empty queries are not reranked.
+type Diversity struct {
+	// Lambda enables maximal marginal relevance (MMR): each next hit is
+	// the one maximizing Lambda·relevance − (1−Lambda)·redundancy, where
+	// relevance is its score divided by the top score and redundancy is 1
+	// if a hit of its namespace is already ranked, else 0. Lower values
+	// favor spread; 0 or 1 and above disable MMR.
+	Lambda float64
+
+	// MaxPerNamespace caps the hits of one namespace ranked ahead of
+	// other namespaces' hits. Hits over the cap move below all others
+	// rather than being dropped, so results still fill limit. 0 = no cap.
+	MaxPerNamespace int
+}
+
+// diversityPool is how many matches per result diversification reranks.
+const diversityPool = 5
+
+func (d Diversity) enabled() bool {
+	return d.mmr() || d.MaxPerNamespace > 0
+}
+
+func (d Diversity) mmr() bool {
+	return d.Lambda > 0 && d.Lambda < 1
+}
+
+// pool returns the number of matches to fetch for limit results.
+func (d Diversity) pool(limit int) int {
+	if !d.enabled() {
+		return limit
+	}
+	return limit * diversityPool
+}
+
+// rerank reorders hits, which are in BM25 order, and returns the first
+// limit.
+func (d Diversity) rerank(hits []Hit, limit int) []Hit {
+	if d.mmr() {
+		hits = rerankMMR(hits, d.Lambda)
+	}
+	if d.MaxPerNamespace > 0 {
+		hits = capNamespaces(hits, d.MaxPerNamespace)
+	}
+	return hits[:min(limit, len(hits))]
+}
+
+// rerankMMR orders hits greedily by marginal relevance. Ties go to the
+// hit ranked first by BM25.
+func rerankMMR(hits []Hit, lambda float64) []Hit {
+	if len(hits) == 0 {
+		return hits
+	}
+	top := hits[0].Score
+	relevance := func(h Hit) float64 {
+		if top <= 0 {
+			return 1
+		}
+		return h.Score / top
+	}
+
+	out := make([]Hit, 0, len(hits))
+	remaining := append([]Hit(nil), hits...)
+	ranked := make(map[string]bool) // namespaces with a ranked hit
+	for len(remaining) > 0 {
+		best, bestValue := 0, 0.0
+		for i, h := range remaining {
+			value := lambda * relevance(h)
+			if ranked[h.Summary.Namespace] {
+				value -= 1 - lambda
+			}
+			if i == 0 || value > bestValue {
+				best, bestValue = i, value
+			}
+		}
+		h := remaining[best]
+		out = append(out, h)
+		ranked[h.Summary.Namespace] = true
+		remaining = append(remaining[:best], remaining[best+1:]...)
+	}
+	return out
+}
+
+// capNamespaces moves hits beyond the first n of their namespace after
+// all other hits, keeping relative order within both groups.
+func capNamespaces(hits []Hit, n int) []Hit {
+	out := make([]Hit, 0, len(hits))
+	var over []Hit
+	counts := make(map[string]int)
+	for _, h := range hits {
+		counts[h.Summary.Namespace]++
+		if counts[h.Summary.Namespace] > n {
+			over = append(over, h)
+		} else {
+			out = append(out, h)
+		}
+	}
+	return append(out, over...)
+}
@@ -0,0 +1,98 @@
+package toolsearch_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jonwraymond/toolindex"
+	"github.com/jonwraymond/toolmodel"
+	"github.com/jonwraymond/toolsearch"
+)
+
+func TestSearch_Diversity(t *testing.T) {
+	docs := exampleCatalog(t).Docs
+	tests := []struct {
+		name      string
+		diversity toolsearch.Diversity
+		query     string
+		limit     int
+		want      []string
+	}{
+		{"bm25 order", toolsearch.Diversity{}, "devops", 2, []string{"docker:docker_ps", "docker:docker_build"}},
+		{"namespace cap", toolsearch.Diversity{MaxPerNamespace: 1}, "devops", 2, []string{"docker:docker_ps", "kubectl:kubectl_apply"}},
+		// Over-cap hits rank last instead of being dropped.
+		{"cap fills limit", toolsearch.Diversity{MaxPerNamespace: 1}, "devops", 4,
+			[]string{"docker:docker_ps", "kubectl:kubectl_apply", "docker:docker_build", "kubectl:kubectl_get"}},
+		{"mmr", toolsearch.Diversity{Lambda: 0.5}, "list containers resources", 2, []string{"docker:docker_ps", "kubectl:kubectl_get"}},
+		// With little weight on redundancy, a much better hit of a ranked
+		// namespace still wins.
+		{"mmr favors relevance", toolsearch.Diversity{Lambda: 0.99}, "list containers resources", 2, []string{"docker:docker_ps", "docker:docker_build"}},
+		{"mmr disabled", toolsearch.Diversity{Lambda: 1}, "list containers resources", 2, []string{"docker:docker_ps", "docker:docker_build"}},
+		// A ranking of one namespace keeps BM25 order.
+		{"single namespace", toolsearch.Diversity{Lambda: 0.5, MaxPerNamespace: 1}, "git", 3,
+			[]string{"git:git_commit", "git:git_status", "git:git_push"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := toolsearch.NewBM25Searcher(toolsearch.BM25Config{Diversity: tt.diversity, CacheSize: 8})
+			defer func() { _ = s.Close() }()
+			plain := toolsearch.NewBM25Searcher(toolsearch.BM25Config{})
+			defer func() { _ = plain.Close() }()
+
+			var first []toolsearch.Hit
+			for i := range 3 { // later searches are served from the cache
+				result, err := s.SearchWithOptions(tt.query, tt.limit, docs, toolsearch.SearchOptions{})
+				if err != nil {
+					t.Fatalf("search error: %v", err)
+				}
+				if got := hitIDs(result.Hits); !reflect.DeepEqual(got, tt.want) {
+					t.Fatalf("hits = %v, want %v", got, tt.want)
+				}
+				if i == 0 {
+					first = result.Hits
+				} else if !reflect.DeepEqual(result.Hits, first) {
+					t.Errorf("search %d = %+v, want %+v", i, result.Hits, first)
+				}
+			}
+
+			// Reranking keeps BM25 scores.
+			all, err := plain.SearchWithOptions(tt.query, len(docs), docs, toolsearch.SearchOptions{})
+			if err != nil {
+				t.Fatalf("search error: %v", err)
+			}
+			scores := make(map[string]float64)
+			for _, hit := range all.Hits {
+				scores[hit.Summary.ID] = hit.Score
+			}
+			for _, hit := range first {
+				if hit.Score != scores[hit.Summary.ID] {
+					t.Errorf("%s score %v, want %v", hit.Summary.ID, hit.Score, scores[hit.Summary.ID])
+				}
+			}
+		})
+	}
+}
+
+func TestSearch_DiversityToolindex(t *testing.T) {
+	idx := toolindex.NewInMemoryIndex(toolindex.IndexOptions{
+		Searcher: toolsearch.NewBM25Searcher(toolsearch.BM25Config{Diversity: toolsearch.Diversity{Lambda: 0.5}}),
+	})
+	backend := toolmodel.ToolBackend{Kind: toolmodel.BackendKindMCP, MCP: &toolmodel.MCPBackend{ServerName: "devops-mcp"}}
+	for _, tool := range exampleCatalog(t).Tools {
+		if err := idx.RegisterTool(tool, backend); err != nil {
+			t.Fatalf("RegisterTool(%s) error: %v", tool.Name, err)
+		}
+	}
+
+	results, err := idx.Search("devops", 2)
+	if err != nil {
+		t.Fatalf("Search error: %v", err)
+	}
+	var namespaces []string
+	for _, r := range results {
+		namespaces = append(namespaces, r.Namespace)
+	}
+	if want := []string{"docker", "kubectl"}; !reflect.DeepEqual(namespaces, want) {
+		t.Errorf("namespaces = %v, want %v", namespaces, want)
+	}
+}
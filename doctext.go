@@ -0,0 +1,143 @@
+package toolsearch
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DocTextMode selects how DocText is shortened to MaxDocTextLen.
+type DocTextMode int
+
+const (
+	// DocTextPrefix keeps the beginning of DocText.
+	DocTextPrefix DocTextMode = iota
+
+	// DocTextSections splits DocText into paragraphs at blank lines and
+	// keeps the first paragraph, then parameter sections, then the rest,
+	// in that priority, until MaxDocTextLen is spent. Kept paragraphs
+	// stay in their original order.
+	DocTextSections
+)
+
+// truncateDocText shortens text to at most n bytes. It never splits a
+// rune, and drops a word that would be cut unless no word boundary
+// precedes it.
+func truncateDocText(text string, n int, mode DocTextMode) string {
+	if len(text) <= n {
+		return text
+	}
+	if mode == DocTextSections {
+		return truncateSections(text, n)
+	}
+	return truncateWords(text, n)
+}
+
+// truncateWords returns the longest prefix of text of at most n bytes
+// that ends at a word boundary, without trailing space. Any rune other
+// than a letter or digit ends a word. Text with no boundary before the
+// cut, such as unspaced CJK, keeps its longest whole-rune prefix.
+func truncateWords(text string, n int) string {
+	if len(text) <= n {
+		return text
+	}
+	// Back up to a rune boundary.
+	cut := n
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	// If the cut falls inside a word, drop the partial word.
+	if r, _ := utf8.DecodeRuneInString(text[cut:]); !isWordBoundary(r) {
+		if i := strings.LastIndexFunc(text[:cut], isWordBoundary); i >= 0 {
+			cut = i
+		}
+	}
+	return strings.TrimRightFunc(text[:cut], unicode.IsSpace)
+}
+
+func isWordBoundary(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+var paragraphBreak = regexp.MustCompile(`\n[ \t]*\n`)
+
+// paramHeaders start the first line of a parameter section.
+var paramHeaders = []string{"parameters", "params", "arguments", "args", "inputs", "options", "flags", "@param", ":param"}
+
+// isParamSection reports whether a paragraph documents parameters.
+func isParamSection(p string) bool {
+	first, _, _ := strings.Cut(strings.TrimSpace(p), "\n")
+	first = strings.ToLower(first)
+	for _, h := range paramHeaders {
+		if strings.HasPrefix(first, h) {
+			return true
+		}
+	}
+	return false
+}
+
+// truncateSections keeps whole paragraphs by priority, cutting the first
+// one that does not fit at a word boundary.
+func truncateSections(text string, n int) string {
+	paras := paragraphBreak.Split(text, -1)
+	if len(paras) == 1 {
+		return truncateWords(text, n)
+	}
+
+	rank := func(i int) int {
+		switch {
+		case i == 0:
+			return 0
+		case isParamSection(paras[i]):
+			return 1
+		default:
+			return 2
+		}
+	}
+	order := make([]int, len(paras))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int { return rank(a) - rank(b) })
+
+	const sep = "\n\n"
+	kept := make([]string, len(paras))
+	left := n
+	for _, i := range order {
+		p := strings.TrimSpace(paras[i])
+		if p == "" {
+			continue
+		}
+		cost := len(p)
+		if left < n {
+			cost += len(sep)
+		}
+		if cost <= left {
+			kept[i] = p
+			left -= cost
+			continue
+		}
+		if left < n {
+			left -= len(sep)
+		}
+		if left > 0 {
+			// Unlike a whole DocText, a paragraph is not worth keeping as
+			// a fragment of its first word.
+			t := truncateWords(p, left)
+			if r, _ := utf8.DecodeRuneInString(p[len(t):]); isWordBoundary(r) {
+				kept[i] = t
+			}
+		}
+		break
+	}
+
+	out := kept[:0]
+	for _, p := range kept {
+		if p != "" {
+			out = append(out, p)
+		}
+	}
+	return strings.Join(out, sep)
+}
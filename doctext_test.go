@@ -0,0 +1,68 @@
+package toolsearch
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateWords(t *testing.T) {
+	cases := []struct {
+		text string
+		n    int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"hello world", 11, "hello world"},
+		{"hello world", 8, "hello"},
+		{"hello world", 6, "hello"},
+		{"hello world", 5, "hello"},
+		{"hello world", 4, "hell"},
+		{"hello   world", 7, "hello"},
+		{"日本語 ツール", 10, "日本語"},
+		{"日本語 ツール", 8, "日本"},
+		{"日本語ツール", 10, "日本語"},
+		{"create_issue,open the issue", 12, "create_issue"},
+		{"create_issue,open the issue", 15, "create_issue"},
+		{"create_issue", 9, "create"},
+		{"a\nnew line", 3, "a"},
+	}
+	for _, tc := range cases {
+		got := truncateWords(tc.text, tc.n)
+		if got != tc.want {
+			t.Errorf("truncateWords(%q, %d) = %q, want %q", tc.text, tc.n, got, tc.want)
+		}
+		if len(got) > tc.n || !utf8.ValidString(got) {
+			t.Errorf("truncateWords(%q, %d) = %q exceeds the limit or splits a rune", tc.text, tc.n, got)
+		}
+	}
+}
+
+func TestTruncateSections(t *testing.T) {
+	text := strings.Join([]string{
+		"create an issue in a repository.",
+		"this tool talks to the rest api and retries on rate limits with backoff.",
+		"parameters:\n- title: issue title\n- body: issue body",
+		"see also: list issues.",
+	}, "\n\n")
+
+	got := truncateDocText(text, 90, DocTextSections)
+	want := "create an issue in a repository.\n\nparameters:\n- title: issue title\n- body: issue body"
+	if got != want {
+		t.Errorf("sections = %q, want %q", got, want)
+	}
+
+	// The first section that does not fit is cut at a word boundary.
+	got = truncateDocText(text, 110, DocTextSections)
+	if !strings.HasPrefix(got, "create an issue") || !strings.Contains(got, "parameters:") || len(got) > 110 {
+		t.Errorf("sections = %q", got)
+	}
+	if !strings.Contains(got, "this tool talks to the\n\n") || strings.Contains(got, "see also") {
+		t.Errorf("sections = %q, want the second section cut at a word", got)
+	}
+
+	// Without paragraphs, sections mode keeps the prefix.
+	if got := truncateDocText("one two three", 8, DocTextSections); got != "one two" {
+		t.Errorf("single paragraph = %q, want %q", got, "one two")
+	}
+}
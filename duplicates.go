@@ -0,0 +1,184 @@
+package toolsearch
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis"
+	"github.com/jonwraymond/toolindex"
+	"github.com/jonwraymond/toolsearch/internal/cluster"
+)
+
+// DuplicateOptions configures FindDuplicates.
+type DuplicateOptions struct {
+	// Threshold is the Jaccard similarity of two tools' word sets at or
+	// above which they are near duplicates (default 0.7).
+	Threshold float64
+}
+
+// FindDuplicates groups near-duplicate tools, such as the same tool
+// exposed by two MCP servers. Two docs are near duplicates when the sets
+// of words in their DocText, not counting English stop words and words
+// of their own namespace, have a Jaccard similarity of at least
+// Threshold; clusters are the groups linked by such pairs. Words are
+// runs of letters and digits, so "create_issue" and "issue create" share
+// both words.
+//
+// Clusters of two or more IDs are returned, each sorted by ID and ordered
+// by their first ID. Only pairs sharing one of their rarest words are
+// compared (a prefix filter, which never misses a pair), so large
+// catalogs cluster in about linear time.
+func FindDuplicates(docs []toolindex.SearchDoc, opts DuplicateOptions) [][]string {
+	t := opts.Threshold
+	if t <= 0 {
+		t = 0.7
+	}
+	docs = sortDocsByID(docs)
+
+	analyzer := bleve.NewIndexMapping().AnalyzerNamed("standard")
+	groups := cluster.Similar(len(docs), func(i int) []string {
+		return duplicateWords(analyzer, docs[i])
+	}, t)
+	var clusters [][]string
+	for _, group := range groups {
+		ids := make([]string, len(group))
+		for k, i := range group {
+			ids[k] = docs[i].ID
+		}
+		clusters = append(clusters, ids)
+	}
+	return clusters
+}
+
+// duplicateWords returns the distinct words of doc's DocText, analyzed
+// as the index analyzes them, that are not words of its namespace.
+func duplicateWords(analyzer analysis.Analyzer, doc toolindex.SearchDoc) []string {
+	namespace := splitWords(analyzer, doc.Summary.Namespace)
+	ws := slices.DeleteFunc(splitWords(analyzer, doc.DocText), func(w string) bool {
+		return slices.Contains(namespace, w)
+	})
+	slices.Sort(ws)
+	return slices.Compact(ws)
+}
+
+// splitWords analyzes s after splitting it at every rune other than a
+// letter or digit, which the analyzer keeps inside words such as
+// "create_issue".
+func splitWords(analyzer analysis.Analyzer, s string) []string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return ' '
+	}, s)
+	var ws []string
+	for _, tok := range analyzer.Analyze([]byte(s)) {
+		ws = append(ws, string(tok.Term))
+	}
+	return ws
+}
+
+// duplicateIndex maps the IDs of an index's docs to their clusters.
+type duplicateIndex struct {
+	fingerprint string
+	clusters    [][]string
+	cluster     map[string]int // doc ID -> index in clusters
+	extra       int            // docs beyond the first of each cluster
+}
+
+func newDuplicateIndex(docs []toolindex.SearchDoc, fingerprint string, opts DuplicateOptions) *duplicateIndex {
+	d := &duplicateIndex{fingerprint: fingerprint, clusters: FindDuplicates(docs, opts), cluster: make(map[string]int)}
+	for i, ids := range d.clusters {
+		for _, id := range ids {
+			d.cluster[id] = i
+		}
+		d.extra += len(ids) - 1
+	}
+	return d
+}
+
+// Duplicates returns the near-duplicate clusters of the current index, as
+// FindDuplicates with BM25Config.DuplicateThreshold finds them. They are
+// computed on first use after each change. It returns nil before the
+// first build.
+func (s *BM25Searcher) Duplicates() [][]string {
+	d := s.duplicates()
+	if d == nil {
+		return nil
+	}
+	out := make([][]string, len(d.clusters))
+	for i, ids := range d.clusters {
+		out[i] = slices.Clone(ids)
+	}
+	return out
+}
+
+// duplicates returns the clusters of the current index, computing and
+// caching them if needed.
+func (s *BM25Searcher) duplicates() *duplicateIndex {
+	s.mu.RLock()
+	if s.index == nil || s.lastSorted == nil {
+		s.mu.RUnlock()
+		return nil
+	}
+	if s.dups != nil && s.dups.fingerprint == s.lastFingerprint {
+		d := s.dups
+		s.mu.RUnlock()
+		return d
+	}
+	sorted, fingerprint := s.lastSorted, s.lastFingerprint
+	s.mu.RUnlock()
+
+	d := newDuplicateIndex(sorted, fingerprint, DuplicateOptions{Threshold: s.cfg.DuplicateThreshold})
+
+	s.mu.Lock()
+	if s.lastFingerprint == fingerprint {
+		s.dups = d
+	}
+	s.mu.Unlock()
+	return d
+}
+
+// collapse keeps the best-ranked hit of each cluster, attaching the other
+// visible members as alternates: those among hits in rank order, then the
+// rest by ID. It returns at most limit hits.
+func (d *duplicateIndex) collapse(hits []Hit, limit int, summaries map[string]toolindex.Summary, vis *Visibility) []Hit {
+	out := make([]Hit, 0, min(limit, len(hits)))
+	rep := make(map[int]int)        // cluster -> index in out, or -1 past limit
+	ranked := make(map[string]bool) // IDs among hits
+	for _, hit := range hits {
+		ranked[hit.Summary.ID] = true
+	}
+	for _, hit := range hits {
+		c, ok := d.cluster[hit.Summary.ID]
+		if !ok {
+			if len(out) < limit {
+				out = append(out, hit)
+			}
+			continue
+		}
+		i, seen := rep[c]
+		switch {
+		case !seen && len(out) < limit:
+			rep[c] = len(out)
+			out = append(out, hit)
+		case !seen:
+			rep[c] = -1
+		case i >= 0:
+			out[i].Alternates = append(out[i].Alternates, hit.Summary)
+		}
+	}
+	for c, i := range rep {
+		if i < 0 {
+			continue
+		}
+		for _, id := range d.clusters[c] {
+			if summary, ok := summaries[id]; ok && !ranked[id] && vis.Allows(summary) {
+				out[i].Alternates = append(out[i].Alternates, summary)
+			}
+		}
+	}
+	return out
+}
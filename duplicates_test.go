@@ -0,0 +1,177 @@
+package toolsearch
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/jonwraymond/toolindex"
+	"github.com/jonwraymond/toolmodel"
+	"github.com/jonwraymond/toolsearch/internal/cluster"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func serverTool(namespace, name, description string) toolindex.SearchDoc {
+	return SearchDocFromTool(toolmodel.Tool{
+		Tool:      mcp.Tool{Name: name, Description: description, InputSchema: map[string]any{"type": "object"}},
+		Namespace: namespace,
+	})
+}
+
+// makeServerDocs returns tools of three MCP servers, two of which expose
+// the same issue tools under other names.
+func makeServerDocs() []toolindex.SearchDoc {
+	return []toolindex.SearchDoc{
+		serverTool("github", "create_issue", "Create an issue in a repository"),
+		serverTool("gh", "issue_create", "Create a new issue in a repository"),
+		serverTool("github", "close_issue", "Close an issue in a repository"),
+		serverTool("gh", "issue_close", "Close an open issue in a repository"),
+		serverTool("jira", "create_ticket", "Create a ticket in a project backlog"),
+		serverTool("github", "create_pull_request", "Create a pull request from a branch"),
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	got := FindDuplicates(makeServerDocs(), DuplicateOptions{})
+	want := [][]string{{"gh:issue_close", "github:close_issue"}, {"gh:issue_create", "github:create_issue"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("clusters = %v, want %v", got, want)
+	}
+	if got := FindDuplicates(makeServerDocs(), DuplicateOptions{Threshold: 0.95}); got != nil {
+		t.Errorf("clusters at 0.95 = %v, want none", got)
+	}
+}
+
+// TestFindDuplicates_MatchesBruteForce checks the prefix filter against
+// comparing every pair.
+func TestFindDuplicates_MatchesBruteForce(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	var docs []toolindex.SearchDoc
+	for i := range 300 {
+		var ws []string
+		n := 3 + r.IntN(20)
+		base := r.IntN(40)
+		for j := range n {
+			w := base + j
+			if r.IntN(10) == 0 {
+				w = r.IntN(200)
+			}
+			ws = append(ws, fmt.Sprintf("w%d", w))
+		}
+		id := fmt.Sprintf("t%03d", i)
+		docs = append(docs, toolindex.SearchDoc{ID: id, DocText: strings.Join(ws, " "), Summary: toolindex.Summary{ID: id}})
+	}
+
+	analyzer := bleve.NewIndexMapping().AnalyzerNamed("standard")
+	sets := make([][]string, len(docs))
+	for i, doc := range docs {
+		sets[i] = duplicateWords(analyzer, doc)
+	}
+	for _, threshold := range []float64{0.5, 0.7, 0.9} {
+		parent := make([]int, len(docs))
+		for i := range parent {
+			parent[i] = i
+		}
+		var find func(int) int
+		find = func(i int) int {
+			if parent[i] != i {
+				parent[i] = find(parent[i])
+			}
+			return parent[i]
+		}
+		for i := range docs {
+			for j := range i {
+				if cluster.Jaccard(sets[i], sets[j]) >= threshold {
+					parent[find(i)] = find(j)
+				}
+			}
+		}
+		members := make(map[int][]string)
+		var roots []int
+		for i, doc := range docs {
+			root := find(i)
+			if members[root] == nil {
+				roots = append(roots, root)
+			}
+			members[root] = append(members[root], doc.ID)
+		}
+		var want [][]string
+		for _, root := range roots {
+			if len(members[root]) > 1 {
+				want = append(want, members[root])
+			}
+		}
+		if len(want) == 0 {
+			t.Fatalf("threshold %v: corpus has no near duplicates", threshold)
+		}
+		if got := FindDuplicates(docs, DuplicateOptions{Threshold: threshold}); !reflect.DeepEqual(got, want) {
+			t.Errorf("threshold %v: clusters\n%v\nwant\n%v", threshold, got, want)
+		}
+	}
+}
+
+func hitIDs(hits []Hit) []string {
+	ids := make([]string, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.Summary.ID
+	}
+	return ids
+}
+
+func alternateIDs(hit Hit) []string {
+	var ids []string
+	for _, alt := range hit.Alternates {
+		ids = append(ids, alt.ID)
+	}
+	return ids
+}
+
+func TestSearch_Collapse(t *testing.T) {
+	s := NewBM25Searcher(BM25Config{CacheSize: 8})
+	defer func() { _ = s.Close() }()
+	docs := makeServerDocs()
+
+	plain, err := s.SearchWithOptions("create issue", 3, docs, SearchOptions{})
+	if err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	if got := hitIDs(plain.Hits)[:2]; !reflect.DeepEqual(got, []string{"github:create_issue", "gh:issue_create"}) {
+		t.Fatalf("uncollapsed top hits = %v, want both issue creators", got)
+	}
+
+	for range 2 { // the second search is served from the cache
+		result, err := s.SearchWithOptions("create issue", 3, docs, SearchOptions{Collapse: true})
+		if err != nil {
+			t.Fatalf("search error: %v", err)
+		}
+		if got, want := hitIDs(result.Hits), []string{"github:create_issue", "github:close_issue", "github:create_pull_request"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("collapsed hits = %v, want %v", got, want)
+		}
+		if got := alternateIDs(result.Hits[0]); !reflect.DeepEqual(got, []string{"gh:issue_create"}) {
+			t.Errorf("alternates = %v", got)
+		}
+		// The close tools matched too, but only one fits the limit.
+		if got := alternateIDs(result.Hits[1]); !reflect.DeepEqual(got, []string{"gh:issue_close"}) {
+			t.Errorf("alternates = %v", got)
+		}
+		if result.Hits[0].Score != plain.Hits[0].Score {
+			t.Errorf("collapsed score %v, want %v", result.Hits[0].Score, plain.Hits[0].Score)
+		}
+	}
+
+	vis := &Visibility{ExcludeNamespaces: []string{"github"}}
+	result, err := s.SearchWithOptions("create issue", 1, docs, SearchOptions{Collapse: true, Visibility: vis})
+	if err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	if len(result.Hits) != 1 || result.Hits[0].Summary.ID != "gh:issue_create" || len(result.Hits[0].Alternates) != 0 {
+		t.Errorf("hits visible to gh only = %+v", result.Hits)
+	}
+
+	if got := s.Duplicates(); !reflect.DeepEqual(got, FindDuplicates(docs, DuplicateOptions{})) {
+		t.Errorf("Duplicates() = %v", got)
+	}
+}
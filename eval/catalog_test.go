@@ -0,0 +1,87 @@
+package eval_test
+
+import (
+	"testing"
+
+	"github.com/jonwraymond/toolindex"
+	"github.com/jonwraymond/toolmodel"
+	"github.com/jonwraymond/toolsearch/internal/catalog"
+)
+
+// captureSearcher records the docs toolindex hands to its searcher.
+type captureSearcher struct {
+	docs []toolindex.SearchDoc
+}
+
+func (c *captureSearcher) Search(_ string, _ int, docs []toolindex.SearchDoc) ([]toolindex.Summary, error) {
+	c.docs = docs
+	return nil, nil
+}
+
+func (c *captureSearcher) Deterministic() bool { return true }
+
+// exampleCatalog combines the tool catalogs used by the programs in example/.
+// The toolindex_integration tools are registered through toolindex so their
+// DocText matches what a real index produces.
+func exampleCatalog(t *testing.T) []toolindex.SearchDoc {
+	t.Helper()
+
+	docs := []toolindex.SearchDoc{
+		// example/basic
+		{
+			ID:      "git:status",
+			DocText: "git status show working tree status version control",
+			Summary: toolindex.Summary{ID: "git:status", Name: "status", Namespace: "git", ShortDescription: "Show the working tree status", Tags: []string{"vcs", "git"}},
+		},
+		{
+			ID:      "git:commit",
+			DocText: "git commit save changes to repository version control",
+			Summary: toolindex.Summary{ID: "git:commit", Name: "commit", Namespace: "git", ShortDescription: "Record changes to the repository", Tags: []string{"vcs", "git"}},
+		},
+		{
+			ID:      "docker:ps",
+			DocText: "docker ps list containers running processes",
+			Summary: toolindex.Summary{ID: "docker:ps", Name: "ps", Namespace: "docker", ShortDescription: "List containers", Tags: []string{"containers", "docker"}},
+		},
+		{
+			ID:      "kubectl:get",
+			DocText: "kubectl get display resources kubernetes pods services",
+			Summary: toolindex.Summary{ID: "kubectl:get", Name: "get", Namespace: "kubectl", ShortDescription: "Display one or many resources", Tags: []string{"kubernetes", "k8s"}},
+		},
+		// example/custom_config
+		{
+			ID:      "ci:deploy",
+			DocText: "deploy application to production continuous integration",
+			Summary: toolindex.Summary{ID: "ci:deploy", Name: "deploy", Namespace: "ci", ShortDescription: "Deploy application to production", Tags: []string{"ci", "cd"}},
+		},
+		{
+			ID:      "ops:rollout",
+			DocText: "rollout deploy new version gradually canary deployment",
+			Summary: toolindex.Summary{ID: "ops:rollout", Name: "rollout", Namespace: "ops", ShortDescription: "Gradually deploy new version", Tags: []string{"deployment"}},
+		},
+		{
+			ID:      "k8s:apply",
+			DocText: "apply kubernetes manifest deploy resources yaml",
+			Summary: toolindex.Summary{ID: "k8s:apply", Name: "apply", Namespace: "k8s", ShortDescription: "Apply a configuration to deploy resources", Tags: []string{"kubernetes"}},
+		},
+	}
+
+	// example/toolindex_integration, shared with the other packages' tests
+	integration, err := catalog.Load("../testdata/toolindex_integration.jsonl", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	capture := &captureSearcher{}
+	idx := toolindex.NewInMemoryIndex(toolindex.IndexOptions{Searcher: capture})
+	backend := toolmodel.ToolBackend{Kind: toolmodel.BackendKindMCP, MCP: &toolmodel.MCPBackend{ServerName: "example-mcp"}}
+	for _, tool := range integration.Tools {
+		if err := idx.RegisterTool(tool, backend); err != nil {
+			t.Fatalf("register %s: %v", tool.Name, err)
+		}
+	}
+	if _, err := idx.Search("", 100); err != nil {
+		t.Fatalf("capture docs: %v", err)
+	}
+
+	return append(docs, capture.docs...)
+}
@@ -0,0 +1,252 @@
+package eval
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jonwraymond/toolindex"
+)
+
+// CompareOptions configures Compare.
+type CompareOptions struct {
+	// K is the number of results fetched from each searcher (default 10).
+	K int
+
+	// Judgments, when set, adds per-query metrics for both sides. If no
+	// queries are passed to Compare, the judged queries are used.
+	Judgments []Judgment
+}
+
+// RankChange records a result present on both sides at different ranks.
+// Ranks are 1-based.
+type RankChange struct {
+	ID    string `json:"id"`
+	RankA int    `json:"rank_a"`
+	RankB int    `json:"rank_b"`
+}
+
+// QueryComparison describes how the ranking of one query differs.
+type QueryComparison struct {
+	Query   string       `json:"query"`
+	A       []string     `json:"a"`
+	B       []string     `json:"b"`
+	Changed bool         `json:"changed"`
+	Moved   []RankChange `json:"moved,omitempty"`
+	Added   []string     `json:"added,omitempty"`
+	Dropped []string     `json:"dropped,omitempty"`
+
+	KendallTau float64 `json:"kendall_tau"`
+	Overlap    float64 `json:"overlap"`
+
+	MetricsA *Metrics `json:"metrics_a,omitempty"`
+	MetricsB *Metrics `json:"metrics_b,omitempty"`
+}
+
+// Comparison is the result of running two searchers over the same queries.
+type Comparison struct {
+	K       int               `json:"k"`
+	Queries []QueryComparison `json:"queries"`
+
+	Changed        int     `json:"changed"`
+	MeanKendallTau float64 `json:"mean_kendall_tau"`
+	MeanOverlap    float64 `json:"mean_overlap"`
+
+	MeanA *Metrics `json:"mean_a,omitempty"`
+	MeanB *Metrics `json:"mean_b,omitempty"`
+}
+
+// Compare runs queries through searchers a and b over the same docs and
+// reports rank deltas, added and dropped results, Kendall tau and
+// overlap@K for each query.
+//
+// Kendall tau is computed over results present in both top-K lists; with
+// fewer than two shared results it is 1. Overlap is the number of shared
+// results divided by K.
+func Compare(a, b toolindex.Searcher, docs []toolindex.SearchDoc, queries []string, opts CompareOptions) (Comparison, error) {
+	if opts.K <= 0 {
+		opts.K = 10
+	}
+
+	judged := make(map[string]map[string]int, len(opts.Judgments))
+	for _, j := range opts.Judgments {
+		if err := j.validate(); err != nil {
+			return Comparison{}, err
+		}
+		judged[j.Query] = j.Relevant
+	}
+	if len(queries) == 0 {
+		for _, j := range opts.Judgments {
+			queries = append(queries, j.Query)
+		}
+	}
+
+	cmp := Comparison{K: opts.K, Queries: make([]QueryComparison, 0, len(queries))}
+	var sumA, sumB Metrics
+	judgedCount := 0
+	for _, q := range queries {
+		idsA, err := searchIDs(a, q, opts.K, docs)
+		if err != nil {
+			return Comparison{}, fmt.Errorf("searcher a: %w", err)
+		}
+		idsB, err := searchIDs(b, q, opts.K, docs)
+		if err != nil {
+			return Comparison{}, fmt.Errorf("searcher b: %w", err)
+		}
+
+		qc := compareRankings(q, idsA, idsB, opts.K)
+		if relevant, ok := judged[q]; ok {
+			ma, mb := Score(idsA, relevant, opts.K), Score(idsB, relevant, opts.K)
+			qc.MetricsA, qc.MetricsB = &ma, &mb
+			sumA = addMetrics(sumA, ma)
+			sumB = addMetrics(sumB, mb)
+			judgedCount++
+		}
+
+		if qc.Changed {
+			cmp.Changed++
+		}
+		cmp.MeanKendallTau += qc.KendallTau
+		cmp.MeanOverlap += qc.Overlap
+		cmp.Queries = append(cmp.Queries, qc)
+	}
+	if n := float64(len(cmp.Queries)); n > 0 {
+		cmp.MeanKendallTau /= n
+		cmp.MeanOverlap /= n
+	}
+	if judgedCount > 0 {
+		ma, mb := scaleMetrics(sumA, judgedCount), scaleMetrics(sumB, judgedCount)
+		cmp.MeanA, cmp.MeanB = &ma, &mb
+	}
+	return cmp, nil
+}
+
+func searchIDs(s toolindex.Searcher, query string, k int, docs []toolindex.SearchDoc) ([]string, error) {
+	results, err := s.Search(query, k, docs)
+	if err != nil {
+		return nil, fmt.Errorf("search %q: %w", query, err)
+	}
+	ids := make([]string, len(results))
+	for i, r := range results {
+		ids[i] = r.ID
+	}
+	return ids, nil
+}
+
+// compareRankings diffs two ranked ID lists for a single query.
+func compareRankings(query string, a, b []string, k int) QueryComparison {
+	qc := QueryComparison{Query: query, A: a, B: b, Changed: !slices.Equal(a, b)}
+
+	rankB := make(map[string]int, len(b))
+	for i, id := range b {
+		rankB[id] = i + 1
+	}
+	var shared []string
+	for i, id := range a {
+		rb, ok := rankB[id]
+		if !ok {
+			qc.Dropped = append(qc.Dropped, id)
+			continue
+		}
+		shared = append(shared, id)
+		if rb != i+1 {
+			qc.Moved = append(qc.Moved, RankChange{ID: id, RankA: i + 1, RankB: rb})
+		}
+	}
+	inA := make(map[string]struct{}, len(a))
+	for _, id := range a {
+		inA[id] = struct{}{}
+	}
+	for _, id := range b {
+		if _, ok := inA[id]; !ok {
+			qc.Added = append(qc.Added, id)
+		}
+	}
+
+	qc.KendallTau = kendallTau(shared, rankB)
+	qc.Overlap = float64(len(shared)) / float64(k)
+	return qc
+}
+
+// kendallTau computes tau over shared, which is ordered by rank in A,
+// using the B-side ranks in rankB.
+func kendallTau(shared []string, rankB map[string]int) float64 {
+	n := len(shared)
+	if n < 2 {
+		return 1
+	}
+	concordant, discordant := 0, 0
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			if rankB[shared[i]] < rankB[shared[j]] {
+				concordant++
+			} else {
+				discordant++
+			}
+		}
+	}
+	return float64(concordant-discordant) / float64(n*(n-1)/2)
+}
+
+func addMetrics(a, b Metrics) Metrics {
+	return Metrics{
+		NDCG:      a.NDCG + b.NDCG,
+		MRR:       a.MRR + b.MRR,
+		Precision: a.Precision + b.Precision,
+		Recall:    a.Recall + b.Recall,
+	}
+}
+
+func scaleMetrics(m Metrics, n int) Metrics {
+	d := float64(n)
+	return Metrics{NDCG: m.NDCG / d, MRR: m.MRR / d, Precision: m.Precision / d, Recall: m.Recall / d}
+}
+
+// WriteText renders the comparison as plain text: a summary line, then
+// one block per query whose ranking changed.
+func (c Comparison) WriteText(w io.Writer) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d of %d queries changed (k=%d, mean tau %.3f, mean overlap %.3f)\n",
+		c.Changed, len(c.Queries), c.K, c.MeanKendallTau, c.MeanOverlap)
+	if c.MeanA != nil && c.MeanB != nil {
+		fmt.Fprintf(&sb, "mean NDCG@%d %.3f -> %.3f, MRR %.3f -> %.3f\n",
+			c.K, c.MeanA.NDCG, c.MeanB.NDCG, c.MeanA.MRR, c.MeanB.MRR)
+	}
+
+	for _, q := range c.Queries {
+		if !q.Changed {
+			continue
+		}
+		fmt.Fprintf(&sb, "\nquery %q (tau %.3f, overlap %.3f)\n", q.Query, q.KendallTau, q.Overlap)
+		tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(tw, "  RANK\tA\tB\n")
+		for i := range max(len(q.A), len(q.B)) {
+			fmt.Fprintf(tw, "  %d\t%s\t%s\n", i+1, at(q.A, i), at(q.B, i))
+		}
+		_ = tw.Flush()
+		for _, m := range q.Moved {
+			fmt.Fprintf(&sb, "  moved   %s %d -> %d\n", m.ID, m.RankA, m.RankB)
+		}
+		for _, id := range q.Added {
+			fmt.Fprintf(&sb, "  added   %s\n", id)
+		}
+		for _, id := range q.Dropped {
+			fmt.Fprintf(&sb, "  dropped %s\n", id)
+		}
+		if q.MetricsA != nil && q.MetricsB != nil {
+			fmt.Fprintf(&sb, "  NDCG@%d %.3f -> %.3f\n", c.K, q.MetricsA.NDCG, q.MetricsB.NDCG)
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func at(ids []string, i int) string {
+	if i < len(ids) {
+		return ids[i]
+	}
+	return "-"
+}
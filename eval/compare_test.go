@@ -0,0 +1,147 @@
+package eval_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jonwraymond/toolindex"
+	"github.com/jonwraymond/toolsearch"
+	"github.com/jonwraymond/toolsearch/eval"
+)
+
+// fixedSearcher returns canned rankings keyed by query.
+type fixedSearcher map[string][]string
+
+func (f fixedSearcher) Search(query string, limit int, _ []toolindex.SearchDoc) ([]toolindex.Summary, error) {
+	var out []toolindex.Summary
+	for _, id := range f[query] {
+		if len(out) == limit {
+			break
+		}
+		out = append(out, toolindex.Summary{ID: id})
+	}
+	return out, nil
+}
+
+func TestCompare_RankDeltas(t *testing.T) {
+	a := fixedSearcher{"q": {"x", "y", "z"}, "same": {"x"}}
+	b := fixedSearcher{"q": {"y", "x", "w"}, "same": {"x"}}
+
+	cmp, err := eval.Compare(a, b, nil, []string{"q", "same"}, eval.CompareOptions{K: 3})
+	if err != nil {
+		t.Fatalf("Compare error: %v", err)
+	}
+	if cmp.Changed != 1 {
+		t.Errorf("Changed = %d, want 1", cmp.Changed)
+	}
+
+	q := cmp.Queries[0]
+	wantMoved := []eval.RankChange{{ID: "x", RankA: 1, RankB: 2}, {ID: "y", RankA: 2, RankB: 1}}
+	if !slices.Equal(q.Moved, wantMoved) {
+		t.Errorf("Moved = %v, want %v", q.Moved, wantMoved)
+	}
+	if !slices.Equal(q.Added, []string{"w"}) || !slices.Equal(q.Dropped, []string{"z"}) {
+		t.Errorf("Added = %v, Dropped = %v", q.Added, q.Dropped)
+	}
+	if q.KendallTau != -1 {
+		t.Errorf("KendallTau = %v, want -1 for a swapped pair", q.KendallTau)
+	}
+	if !approx(q.Overlap, 2.0/3.0) {
+		t.Errorf("Overlap = %v, want 2/3", q.Overlap)
+	}
+	if q.MetricsA != nil {
+		t.Error("expected no metrics without judgments")
+	}
+
+	same := cmp.Queries[1]
+	if same.Changed || same.KendallTau != 1 || len(same.Moved) != 0 {
+		t.Errorf("unexpected diff for identical rankings: %+v", same)
+	}
+}
+
+func TestCompare_WithJudgments(t *testing.T) {
+	a := fixedSearcher{"q": {"x", "y"}}
+	b := fixedSearcher{"q": {"y", "x"}}
+	judgments := []eval.Judgment{{Query: "q", Relevant: map[string]int{"y": 2}}}
+
+	cmp, err := eval.Compare(a, b, nil, nil, eval.CompareOptions{K: 2, Judgments: judgments})
+	if err != nil {
+		t.Fatalf("Compare error: %v", err)
+	}
+	if len(cmp.Queries) != 1 {
+		t.Fatalf("expected judged queries to be used, got %d queries", len(cmp.Queries))
+	}
+	if cmp.MeanA == nil || cmp.MeanB == nil {
+		t.Fatal("expected mean metrics with judgments")
+	}
+	if !approx(cmp.MeanA.MRR, 0.5) || !approx(cmp.MeanB.MRR, 1) {
+		t.Errorf("MRR a=%v b=%v, want 0.5 and 1", cmp.MeanA.MRR, cmp.MeanB.MRR)
+	}
+}
+
+func TestCompare_Render(t *testing.T) {
+	a := fixedSearcher{"q": {"x", "y"}}
+	b := fixedSearcher{"q": {"y", "z"}}
+
+	cmp, err := eval.Compare(a, b, nil, []string{"q"}, eval.CompareOptions{K: 2})
+	if err != nil {
+		t.Fatalf("Compare error: %v", err)
+	}
+
+	var text bytes.Buffer
+	if err := cmp.WriteText(&text); err != nil {
+		t.Fatalf("WriteText error: %v", err)
+	}
+	for _, want := range []string{"1 of 1 queries changed", "moved   y 2 -> 1", "added   z", "dropped x"} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("text output missing %q:\n%s", want, text.String())
+		}
+	}
+
+	data, err := json.Marshal(cmp)
+	if err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	}
+	var decoded eval.Comparison
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	if decoded.Queries[0].Added[0] != "z" {
+		t.Errorf("JSON round trip lost data: %s", data)
+	}
+}
+
+func TestCompare_BoostChangeOnExampleCatalog(t *testing.T) {
+	docs := exampleCatalog(t)
+	judgments, err := eval.LoadJudgments("testdata/example_judgments.jsonl")
+	if err != nil {
+		t.Fatalf("LoadJudgments error: %v", err)
+	}
+
+	a := toolsearch.NewBM25Searcher(toolsearch.BM25Config{})
+	b := toolsearch.NewBM25Searcher(toolsearch.BM25Config{NameBoost: 1, NamespaceBoost: 1, TagsBoost: 1})
+	cmp, err := eval.Compare(a, b, docs, nil, eval.CompareOptions{K: 5, Judgments: judgments})
+	if err != nil {
+		t.Fatalf("Compare error: %v", err)
+	}
+	if len(cmp.Queries) != len(judgments) {
+		t.Errorf("compared %d queries, want %d", len(cmp.Queries), len(judgments))
+	}
+
+	var buf bytes.Buffer
+	if err := cmp.WriteText(&buf); err != nil {
+		t.Fatalf("WriteText error: %v", err)
+	}
+	t.Logf("\n%s", buf.String())
+
+	self, err := eval.Compare(a, a, docs, nil, eval.CompareOptions{K: 5, Judgments: judgments})
+	if err != nil {
+		t.Fatalf("Compare error: %v", err)
+	}
+	if self.Changed != 0 || self.MeanKendallTau != 1 {
+		t.Errorf("comparing a searcher with itself reported changes: %+v", self)
+	}
+}
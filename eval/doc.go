@@ -0,0 +1,19 @@
+// Package eval measures ranking quality of toolindex.Searcher implementations.
+//
+// Relevance judgments map a query to graded relevant tool IDs. They are
+// stored as newline-delimited JSON, one judgment per line:
+//
+//	{"query": "git status", "relevant": {"git:status": 3, "git:commit": 1}}
+//
+// Grades are non-negative integers; higher is more relevant and zero means
+// not relevant. [Evaluate] runs every judged query through a searcher and
+// reports NDCG@k, MRR, precision@k and recall@k per query and averaged:
+//
+//	judgments, err := eval.LoadJudgments("testdata/judgments.jsonl")
+//	report, err := eval.Evaluate(searcher, docs, judgments, eval.Options{K: 5})
+//	fmt.Printf("NDCG@%d: %.3f\n", report.K, report.Mean.NDCG)
+//
+// [Compare] runs two searchers over the same queries and reports which
+// rankings changed, with rank deltas, Kendall tau and overlap@k. Judgments
+// are optional; when present, both sides are also scored.
+package eval
@@ -0,0 +1,245 @@
+package eval
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jonwraymond/toolindex"
+	"github.com/jonwraymond/toolsearch"
+)
+
+// ErrInvalidJudgment is returned for a judgment with no query, a negative
+// grade, or no relevant tool.
+var ErrInvalidJudgment = errors.New("invalid judgment")
+
+// Judgment holds graded relevance for a single query.
+type Judgment struct {
+	Query    string         `json:"query"`
+	Relevant map[string]int `json:"relevant"`
+}
+
+// validate checks that the judgment can be scored.
+func (j Judgment) validate() error {
+	if strings.TrimSpace(j.Query) == "" {
+		return fmt.Errorf("%w: empty query", ErrInvalidJudgment)
+	}
+	positive := false
+	for id, grade := range j.Relevant {
+		if grade < 0 {
+			return fmt.Errorf("%w: query %q: negative grade for %q", ErrInvalidJudgment, j.Query, id)
+		}
+		if grade > 0 {
+			positive = true
+		}
+	}
+	if !positive {
+		return fmt.Errorf("%w: query %q has no relevant tools", ErrInvalidJudgment, j.Query)
+	}
+	return nil
+}
+
+// ReadJudgments decodes newline-delimited JSON judgments.
+// Blank lines are skipped; each judgment is validated.
+func ReadJudgments(r io.Reader) ([]Judgment, error) {
+	var judgments []Judgment
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var j Judgment
+		if err := json.Unmarshal([]byte(text), &j); err != nil {
+			return nil, fmt.Errorf("judgments line %d: %w", line, err)
+		}
+		if err := j.validate(); err != nil {
+			return nil, fmt.Errorf("judgments line %d: %w", line, err)
+		}
+		judgments = append(judgments, j)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read judgments: %w", err)
+	}
+	return judgments, nil
+}
+
+// LoadJudgments reads a judgments file from disk.
+func LoadJudgments(path string) ([]Judgment, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	return ReadJudgments(f)
+}
+
+// maxSelectionGrade caps grades derived from selection counts, so that
+// one popular tool does not dominate NDCG gains.
+const maxSelectionGrade = 3
+
+// JudgmentsFromSelections derives judgments from logged selections, such
+// as a toolsearch query log read with toolsearch.ReadSelectionLog. A
+// tool's grade is the number of times it was selected for the query, up
+// to 3. Queries are grouped after lowercasing and collapsing whitespace
+// and returned in order of first selection; records without a selection
+// are ignored.
+func JudgmentsFromSelections(records []toolsearch.SelectionRecord) []Judgment {
+	var judgments []Judgment
+	index := make(map[string]int)
+	for _, rec := range records {
+		query := strings.Join(strings.Fields(strings.ToLower(rec.Query)), " ")
+		if query == "" || rec.Selected == "" {
+			continue
+		}
+		i, ok := index[query]
+		if !ok {
+			i = len(judgments)
+			index[query] = i
+			judgments = append(judgments, Judgment{Query: query, Relevant: make(map[string]int)})
+		}
+		judgments[i].Relevant[rec.Selected] = min(judgments[i].Relevant[rec.Selected]+1, maxSelectionGrade)
+	}
+	return judgments
+}
+
+// Metrics holds ranking quality measures at a cutoff k.
+type Metrics struct {
+	NDCG      float64 `json:"ndcg"`
+	MRR       float64 `json:"mrr"`
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+}
+
+// QueryReport holds the ranked results and metrics for one judged query.
+type QueryReport struct {
+	Query   string   `json:"query"`
+	Results []string `json:"results"`
+	Metrics
+}
+
+// Report holds per-query and mean metrics for an evaluation run.
+type Report struct {
+	K       int           `json:"k"`
+	Queries []QueryReport `json:"queries"`
+	Mean    Metrics       `json:"mean"`
+}
+
+// Options configures Evaluate.
+type Options struct {
+	K int // rank cutoff (default 10)
+}
+
+// Evaluate runs each judged query through s over docs and scores the
+// top K results. Queries are reported in judgment order.
+func Evaluate(s toolindex.Searcher, docs []toolindex.SearchDoc, judgments []Judgment, opts Options) (Report, error) {
+	if opts.K <= 0 {
+		opts.K = 10
+	}
+
+	report := Report{K: opts.K, Queries: make([]QueryReport, 0, len(judgments))}
+	for _, j := range judgments {
+		if err := j.validate(); err != nil {
+			return Report{}, err
+		}
+		results, err := s.Search(j.Query, opts.K, docs)
+		if err != nil {
+			return Report{}, fmt.Errorf("search %q: %w", j.Query, err)
+		}
+		ids := make([]string, len(results))
+		for i, r := range results {
+			ids[i] = r.ID
+		}
+		m := Score(ids, j.Relevant, opts.K)
+		report.Queries = append(report.Queries, QueryReport{Query: j.Query, Results: ids, Metrics: m})
+
+		report.Mean.NDCG += m.NDCG
+		report.Mean.MRR += m.MRR
+		report.Mean.Precision += m.Precision
+		report.Mean.Recall += m.Recall
+	}
+	if n := float64(len(report.Queries)); n > 0 {
+		report.Mean.NDCG /= n
+		report.Mean.MRR /= n
+		report.Mean.Precision /= n
+		report.Mean.Recall /= n
+	}
+	return report, nil
+}
+
+// Score computes metrics for a ranked list of tool IDs against graded
+// relevance, considering only the first k results.
+//
+// NDCG uses exponential gain (2^grade - 1) with a log2 position discount.
+// Precision is relevant results in the top k divided by k.
+func Score(ranked []string, relevant map[string]int, k int) Metrics {
+	if len(ranked) > k {
+		ranked = ranked[:k]
+	}
+
+	var m Metrics
+	var dcg float64
+	hits := 0
+	for i, id := range ranked {
+		grade := relevant[id]
+		if grade <= 0 {
+			continue
+		}
+		hits++
+		dcg += gain(grade) / math.Log2(float64(i+2))
+		if m.MRR == 0 {
+			m.MRR = 1 / float64(i+1)
+		}
+	}
+
+	grades := make([]int, 0, len(relevant))
+	for _, grade := range relevant {
+		if grade > 0 {
+			grades = append(grades, grade)
+		}
+	}
+	slices.SortFunc(grades, func(a, b int) int { return b - a })
+	var idcg float64
+	for i, grade := range grades {
+		if i >= k {
+			break
+		}
+		idcg += gain(grade) / math.Log2(float64(i+2))
+	}
+
+	if idcg > 0 {
+		m.NDCG = dcg / idcg
+	}
+	if len(grades) > 0 {
+		m.Recall = float64(hits) / float64(len(grades))
+	}
+	if k > 0 {
+		m.Precision = float64(hits) / float64(k)
+	}
+	return m
+}
+
+func gain(grade int) float64 {
+	return math.Exp2(float64(grade)) - 1
+}
+
+// WriteText renders the report as an aligned plain-text table.
+func (r Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "QUERY\tNDCG@%d\tMRR\tP@%d\tR@%d\n", r.K, r.K, r.K)
+	for _, q := range r.Queries {
+		fmt.Fprintf(tw, "%s\t%.3f\t%.3f\t%.3f\t%.3f\n", q.Query, q.NDCG, q.MRR, q.Precision, q.Recall)
+	}
+	fmt.Fprintf(tw, "(mean)\t%.3f\t%.3f\t%.3f\t%.3f\n", r.Mean.NDCG, r.Mean.MRR, r.Mean.Precision, r.Mean.Recall)
+	return tw.Flush()
+}
@@ -0,0 +1,159 @@
+package eval_test
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jonwraymond/toolindex"
+	"github.com/jonwraymond/toolsearch"
+	"github.com/jonwraymond/toolsearch/eval"
+)
+
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestScore_PerfectRanking(t *testing.T) {
+	m := eval.Score([]string{"a", "b"}, map[string]int{"a": 3, "b": 1}, 10)
+	if !approx(m.NDCG, 1) || !approx(m.MRR, 1) || !approx(m.Recall, 1) {
+		t.Errorf("unexpected metrics for perfect ranking: %+v", m)
+	}
+	if !approx(m.Precision, 0.2) {
+		t.Errorf("Precision = %v, want 0.2", m.Precision)
+	}
+}
+
+func TestScore_GradedOrdering(t *testing.T) {
+	// Swapping a grade-3 and grade-1 result: DCG = 1 + 7/log2(3), IDCG = 7 + 1/log2(3).
+	m := eval.Score([]string{"b", "a"}, map[string]int{"a": 3, "b": 1}, 2)
+	want := (1 + 7/math.Log2(3)) / (7 + 1/math.Log2(3))
+	if !approx(m.NDCG, want) {
+		t.Errorf("NDCG = %v, want %v", m.NDCG, want)
+	}
+	if !approx(m.MRR, 1) {
+		t.Errorf("MRR = %v, want 1", m.MRR)
+	}
+}
+
+func TestScore_CutoffAndMisses(t *testing.T) {
+	m := eval.Score([]string{"x", "y", "a"}, map[string]int{"a": 2, "b": 2}, 2)
+	if m.NDCG != 0 || m.MRR != 0 || m.Precision != 0 || m.Recall != 0 {
+		t.Errorf("expected zero metrics when relevant results fall below cutoff, got %+v", m)
+	}
+
+	m = eval.Score([]string{"x", "a"}, map[string]int{"a": 2, "b": 2, "c": 0}, 2)
+	if !approx(m.MRR, 0.5) || !approx(m.Recall, 0.5) || !approx(m.Precision, 0.5) {
+		t.Errorf("unexpected metrics: %+v", m)
+	}
+}
+
+func TestReadJudgments_Validation(t *testing.T) {
+	cases := map[string]string{
+		"empty query":   `{"query": " ", "relevant": {"a": 1}}`,
+		"negative":      `{"query": "q", "relevant": {"a": -1}}`,
+		"none relevant": `{"query": "q", "relevant": {"a": 0}}`,
+	}
+	for name, line := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := eval.ReadJudgments(strings.NewReader(line))
+			if !errors.Is(err, eval.ErrInvalidJudgment) {
+				t.Errorf("expected ErrInvalidJudgment, got %v", err)
+			}
+		})
+	}
+}
+
+func TestJudgmentsFromSelections(t *testing.T) {
+	log := `{"query":"Deploy  App","shown":["ci:deploy"],"scores":[1.5],"normalized":"deploy app"}
+{"query":"deploy app","shown":["ci:deploy","ops:rollout"],"selected":"ops:rollout"}
+{"query":"DEPLOY app","selected":"ops:rollout"}
+{"query":"deploy app","selected":"ci:deploy"}
+{"query":"list containers","selected":"docker:ps"}
+`
+	records, err := toolsearch.ReadSelectionLog(strings.NewReader(log))
+	if err != nil {
+		t.Fatalf("ReadSelectionLog error: %v", err)
+	}
+	got := eval.JudgmentsFromSelections(records)
+	want := []eval.Judgment{
+		{Query: "deploy app", Relevant: map[string]int{"ops:rollout": 2, "ci:deploy": 1}},
+		{Query: "list containers", Relevant: map[string]int{"docker:ps": 1}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("JudgmentsFromSelections = %+v, want %+v", got, want)
+	}
+}
+
+func TestEvaluate_Aggregates(t *testing.T) {
+	docs := []toolindex.SearchDoc{
+		{ID: "a", DocText: "alpha", Summary: toolindex.Summary{ID: "a", Name: "alpha"}},
+		{ID: "b", DocText: "beta", Summary: toolindex.Summary{ID: "b", Name: "beta"}},
+	}
+	judgments := []eval.Judgment{
+		{Query: "alpha", Relevant: map[string]int{"a": 1}},
+		{Query: "beta", Relevant: map[string]int{"a": 1}},
+	}
+
+	report, err := eval.Evaluate(toolsearch.NewBM25Searcher(toolsearch.BM25Config{}), docs, judgments, eval.Options{K: 1})
+	if err != nil {
+		t.Fatalf("Evaluate error: %v", err)
+	}
+	if len(report.Queries) != 2 {
+		t.Fatalf("got %d query reports, want 2", len(report.Queries))
+	}
+	if !approx(report.Queries[0].NDCG, 1) || report.Queries[1].NDCG != 0 {
+		t.Errorf("unexpected per-query NDCG: %v, %v", report.Queries[0].NDCG, report.Queries[1].NDCG)
+	}
+	if !approx(report.Mean.NDCG, 0.5) || !approx(report.Mean.MRR, 0.5) {
+		t.Errorf("unexpected mean metrics: %+v", report.Mean)
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteText(&buf); err != nil {
+		t.Fatalf("WriteText error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "NDCG@1") || !strings.Contains(buf.String(), "(mean)") {
+		t.Errorf("unexpected text report:\n%s", buf.String())
+	}
+}
+
+// TestEvaluate_ExampleJudgments guards BM25 ranking quality on the example
+// catalogs. Lower the floors only with a deliberate ranking change.
+func TestEvaluate_ExampleJudgments(t *testing.T) {
+	judgments, err := eval.LoadJudgments("testdata/example_judgments.jsonl")
+	if err != nil {
+		t.Fatalf("LoadJudgments error: %v", err)
+	}
+	docs := exampleCatalog(t)
+
+	s := toolsearch.NewBM25Searcher(toolsearch.BM25Config{})
+	defer func() {
+		if err := s.Close(); err != nil {
+			t.Fatalf("close failed: %v", err)
+		}
+	}()
+
+	report, err := eval.Evaluate(s, docs, judgments, eval.Options{K: 5})
+	if err != nil {
+		t.Fatalf("Evaluate error: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := report.WriteText(&buf); err != nil {
+		t.Fatalf("WriteText error: %v", err)
+	}
+	t.Logf("\n%s", buf.String())
+
+	if report.Mean.NDCG < 0.9 {
+		t.Errorf("mean NDCG@5 = %.3f, want >= 0.9", report.Mean.NDCG)
+	}
+	if report.Mean.MRR < 0.95 {
+		t.Errorf("mean MRR = %.3f, want >= 0.95", report.Mean.MRR)
+	}
+	if report.Mean.Recall < 0.9 {
+		t.Errorf("mean recall@5 = %.3f, want >= 0.9", report.Mean.Recall)
+	}
+}
@@ -2,23 +2,29 @@
 //
 // This example demonstrates:
 // - Injecting BM25Searcher into toolindex.IndexOptions
-// - Real-world tool catalog setup
+// - Real-world tool catalog setup, loaded from a JSONL file
 // - Using toolindex.Search() with BM25 ranking underneath
 //
-// Run: go run ./example/toolindex_integration/
+// Run from the repository root: go run ./example/toolindex_integration/
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
+	"os"
 
 	"github.com/jonwraymond/toolindex"
 	"github.com/jonwraymond/toolmodel"
 	"github.com/jonwraymond/toolsearch"
-	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/jonwraymond/toolsearch/internal/catalog"
 )
 
+var catalogPath = flag.String("catalog", "testdata/toolindex_integration.jsonl", "tool catalog (JSON or JSONL); \"-\" reads stdin")
+
 func main() {
+	flag.Parse()
+
 	// Create a BM25 searcher with custom config
 	searcher := toolsearch.NewBM25Searcher(toolsearch.BM25Config{
 		NameBoost:      3,
@@ -31,73 +37,14 @@ func main() {
 		Searcher: searcher,
 	})
 
-	// Register realistic tools
-	tools := []struct {
-		tool    toolmodel.Tool
-		backend toolmodel.ToolBackend
-	}{
-		{
-			tool: toolmodel.Tool{
-				Tool:      newMCPTool("git_status", "Show the working tree status"),
-				Namespace: "git",
-				Tags:      []string{"vcs", "version-control"},
-			},
-			backend: mcpBackend("git-mcp"),
-		},
-		{
-			tool: toolmodel.Tool{
-				Tool:      newMCPTool("git_commit", "Record changes to the repository"),
-				Namespace: "git",
-				Tags:      []string{"vcs", "version-control"},
-			},
-			backend: mcpBackend("git-mcp"),
-		},
-		{
-			tool: toolmodel.Tool{
-				Tool:      newMCPTool("git_push", "Update remote refs along with associated objects"),
-				Namespace: "git",
-				Tags:      []string{"vcs", "version-control", "remote"},
-			},
-			backend: mcpBackend("git-mcp"),
-		},
-		{
-			tool: toolmodel.Tool{
-				Tool:      newMCPTool("docker_ps", "List containers"),
-				Namespace: "docker",
-				Tags:      []string{"containers", "devops"},
-			},
-			backend: mcpBackend("docker-mcp"),
-		},
-		{
-			tool: toolmodel.Tool{
-				Tool:      newMCPTool("docker_build", "Build an image from a Dockerfile"),
-				Namespace: "docker",
-				Tags:      []string{"containers", "devops", "images"},
-			},
-			backend: mcpBackend("docker-mcp"),
-		},
-		{
-			tool: toolmodel.Tool{
-				Tool:      newMCPTool("kubectl_get", "Display one or many resources"),
-				Namespace: "kubectl",
-				Tags:      []string{"kubernetes", "k8s", "devops"},
-			},
-			backend: mcpBackend("k8s-mcp"),
-		},
-		{
-			tool: toolmodel.Tool{
-				Tool:      newMCPTool("kubectl_apply", "Apply a configuration to a resource"),
-				Namespace: "kubectl",
-				Tags:      []string{"kubernetes", "k8s", "devops"},
-			},
-			backend: mcpBackend("k8s-mcp"),
-		},
+	// Register a realistic catalog, the one the package tests use
+	c, err := catalog.Load(*catalogPath, os.Stdin)
+	if err != nil {
+		log.Fatalf("Failed to load catalog: %v", err)
 	}
-
-	// Register all tools
-	for _, t := range tools {
-		if err := idx.RegisterTool(t.tool, t.backend); err != nil {
-			log.Fatalf("Failed to register %s: %v", t.tool.Name, err)
+	for _, tool := range c.Tools {
+		if err := idx.RegisterTool(tool, mcpBackend(tool.Namespace+"-mcp")); err != nil {
+			log.Fatalf("Failed to register %s: %v", tool.Name, err)
 		}
 	}
 
@@ -134,15 +81,6 @@ func main() {
 	}
 }
 
-// newMCPTool creates a minimal MCP tool for demonstration
-func newMCPTool(name, description string) mcp.Tool {
-	return mcp.Tool{
-		Name:        name,
-		Description: description,
-		InputSchema: map[string]any{"type": "object"},
-	}
-}
-
 // mcpBackend creates an MCP backend for a server
 func mcpBackend(serverName string) toolmodel.ToolBackend {
 	return toolmodel.ToolBackend{
@@ -0,0 +1,52 @@
+package toolsearch
+
+import (
+	"sort"
+
+	"github.com/jonwraymond/toolindex"
+)
+
+// FacetCount is the number of summaries sharing a facet value.
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// Facets summarizes the namespaces and tags of a result set.
+type Facets struct {
+	Namespaces []FacetCount `json:"namespaces"`
+	Tags       []FacetCount `json:"tags"`
+}
+
+// ComputeFacets counts namespaces and tags across summaries. Counts are
+// ordered by count DESC, then value ASC. Empty namespaces are not counted.
+func ComputeFacets(summaries []toolindex.Summary) Facets {
+	namespaces := make(map[string]int)
+	tags := make(map[string]int)
+	for _, s := range summaries {
+		if s.Namespace != "" {
+			namespaces[s.Namespace]++
+		}
+		for _, tag := range s.Tags {
+			tags[tag]++
+		}
+	}
+	return Facets{
+		Namespaces: sortedFacetCounts(namespaces),
+		Tags:       sortedFacetCounts(tags),
+	}
+}
+
+func sortedFacetCounts(counts map[string]int) []FacetCount {
+	out := make([]FacetCount, 0, len(counts))
+	for value, count := range counts {
+		out = append(out, FacetCount{Value: value, Count: count})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Value < out[j].Value
+	})
+	return out
+}
@@ -0,0 +1,35 @@
+package toolsearch
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/jonwraymond/toolindex"
+)
+
+func TestComputeFacets_CountsAndOrder(t *testing.T) {
+	summaries := []toolindex.Summary{
+		{ID: "git:status", Namespace: "git", Tags: []string{"vcs"}},
+		{ID: "git:commit", Namespace: "git", Tags: []string{"vcs", "write"}},
+		{ID: "docker:ps", Namespace: "docker", Tags: []string{"containers"}},
+		{ID: "bare"},
+	}
+
+	facets := ComputeFacets(summaries)
+
+	wantNS := []FacetCount{{"git", 2}, {"docker", 1}}
+	if !slices.Equal(facets.Namespaces, wantNS) {
+		t.Errorf("Namespaces = %v, want %v", facets.Namespaces, wantNS)
+	}
+	wantTags := []FacetCount{{"vcs", 2}, {"containers", 1}, {"write", 1}}
+	if !slices.Equal(facets.Tags, wantTags) {
+		t.Errorf("Tags = %v, want %v", facets.Tags, wantTags)
+	}
+}
+
+func TestComputeFacets_Empty(t *testing.T) {
+	facets := ComputeFacets(nil)
+	if facets.Namespaces == nil || facets.Tags == nil {
+		t.Error("expected empty, non-nil facet slices")
+	}
+}
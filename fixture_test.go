@@ -0,0 +1,27 @@
+package toolsearch_test
+
+import (
+	"testing"
+
+	"github.com/jonwraymond/toolsearch"
+	"github.com/jonwraymond/toolsearch/internal/catalog"
+)
+
+// exampleCatalog loads the catalog of example/toolindex_integration,
+// which the commands and the eval package test against too.
+func exampleCatalog(t *testing.T) catalog.Catalog {
+	t.Helper()
+	c, err := catalog.Load("testdata/toolindex_integration.jsonl", nil)
+	if err != nil {
+		t.Fatalf("load example catalog: %v", err)
+	}
+	return c
+}
+
+func hitIDs(hits []toolsearch.Hit) []string {
+	ids := make([]string, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.Summary.ID
+	}
+	return ids
+}
@@ -0,0 +1,134 @@
+package toolsearch
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jonwraymond/toolindex"
+)
+
+// Run `go test -run TestGolden -update` to regenerate the golden rankings
+// after a deliberate ranking change, and review the diff.
+var update = flag.Bool("update", false, "update golden ranking files")
+
+const goldenDir = "testdata/golden"
+
+func loadGoldenCorpus(t *testing.T) ([]toolindex.SearchDoc, []string) {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join(goldenDir, "catalog.json"))
+	if err != nil {
+		t.Fatalf("read catalog: %v", err)
+	}
+	var docs []toolindex.SearchDoc
+	if err := json.Unmarshal(data, &docs); err != nil {
+		t.Fatalf("decode catalog: %v", err)
+	}
+
+	f, err := os.Open(filepath.Join(goldenDir, "queries.txt"))
+	if err != nil {
+		t.Fatalf("open queries: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+	var queries []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		queries = append(queries, line)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("read queries: %v", err)
+	}
+	return docs, queries
+}
+
+// renderRankings formats the top results of each query in a stable,
+// diff-friendly layout.
+func renderRankings(t *testing.T, s *BM25Searcher, docs []toolindex.SearchDoc, queries []string, limit int) string {
+	t.Helper()
+
+	var sb strings.Builder
+	for i, q := range queries {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(&sb, "query: %s\n", q)
+		results, err := s.Search(q, limit, docs)
+		if err != nil {
+			t.Fatalf("Search(%q) error: %v", q, err)
+		}
+		if len(results) == 0 {
+			sb.WriteString("  (no results)\n")
+		}
+		for rank, r := range results {
+			fmt.Fprintf(&sb, "  %d. %s\n", rank+1, r.ID)
+		}
+	}
+	return sb.String()
+}
+
+func TestGolden_Rankings(t *testing.T) {
+	docs, queries := loadGoldenCorpus(t)
+
+	configs := []struct {
+		name string
+		cfg  BM25Config
+	}{
+		{"default", BM25Config{}},
+		{"flat_boosts", BM25Config{NameBoost: 1, NamespaceBoost: 1, TagsBoost: 1}},
+		{"truncated_doctext", BM25Config{MaxDocTextLen: 40}},
+	}
+
+	for _, tc := range configs {
+		t.Run(tc.name, func(t *testing.T) {
+			s := NewBM25Searcher(tc.cfg)
+			defer func() { _ = s.Close() }()
+
+			got := renderRankings(t, s, docs, queries, 5)
+			path := filepath.Join(goldenDir, tc.name+".golden")
+			if *update {
+				if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+					t.Fatalf("write golden: %v", err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("read golden (run with -update to create): %v", err)
+			}
+			if got != string(want) {
+				t.Errorf("rankings differ from %s; rerun with -update if intended\n%s", path, lineDiff(string(want), got))
+			}
+		})
+	}
+}
+
+// lineDiff reports lines that differ between want and got, by line number.
+func lineDiff(want, got string) string {
+	wl := strings.Split(want, "\n")
+	gl := strings.Split(got, "\n")
+	var sb strings.Builder
+	for i := range max(len(wl), len(gl)) {
+		var w, g string
+		if i < len(wl) {
+			w = wl[i]
+		}
+		if i < len(gl) {
+			g = gl[i]
+		}
+		if w != g {
+			fmt.Fprintf(&sb, "line %d:\n  - %s\n  + %s\n", i+1, w, g)
+		}
+	}
+	return sb.String()
+}
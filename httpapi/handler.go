@@ -0,0 +1,411 @@
+// Package httpapi serves tool search over a local HTTP JSON API.
+//
+// The handler exposes:
+//
+//   - /search        ranked hits for a query
+//   - /suggest       name, namespace and tag completions for a prefix
+//   - /facets        namespace and tag counts of the tools matching a query
+//   - /explain       hits with scoring explanations
+//   - /openapi.json  an OpenAPI 3.1 description generated from the Go types
+//
+// Every endpoint accepts GET with query parameters or POST with a JSON body
+// using the same field names. Errors are returned as {"error": "..."} with
+// a 4xx or 5xx status.
+//
+//	h := httpapi.New(toolsearch.NewBM25Searcher(toolsearch.BM25Config{}),
+//	    httpapi.StaticDocs(docs), httpapi.Options{})
+//	err := http.ListenAndServe("127.0.0.1:8080", h)
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/jonwraymond/toolindex"
+	"github.com/jonwraymond/toolsearch"
+)
+
+// Options configures the handler.
+type Options struct {
+	DefaultLimit int // results when the request sends no limit (default 10)
+	MaxLimit     int // upper bound on a requested limit (default 100)
+	MaxQueryLen  int // maximum query or prefix length in characters (default 256)
+	MaxBodyBytes int // maximum POST body size (default 64 KiB)
+
+	// Visibility, if set, scopes each request to the tools its caller may
+	// see, for example by an authenticated principal. Hidden tools are
+	// excluded from every endpoint's results.
+	Visibility func(r *http.Request) *toolsearch.Visibility
+
+	// Session, if set, names the caller of each request in query logs,
+	// such as an agent conversation ID from a header.
+	Session func(r *http.Request) string
+}
+
+// DocSource returns the documents to search. It is called once per request,
+// so it may return a changing catalog.
+type DocSource func() []toolindex.SearchDoc
+
+// StaticDocs returns a DocSource that always serves docs.
+func StaticDocs(docs []toolindex.SearchDoc) DocSource {
+	return func() []toolindex.SearchDoc { return docs }
+}
+
+// ScoredSearcher is implemented by searchers that report scores and
+// explanations, such as toolsearch.BM25Searcher. Hits from other searchers
+// carry a zero score and /explain responds 501 Not Implemented.
+type ScoredSearcher interface {
+	SearchWithOptions(query string, limit int, docs []toolindex.SearchDoc, opts toolsearch.SearchOptions) (toolsearch.SearchResult, error)
+}
+
+// SearchRequest is the input of /search.
+type SearchRequest struct {
+	Query    string `json:"query" jsonschema:"free-text query"`
+	Limit    int    `json:"limit,omitempty" jsonschema:"maximum number of results; capped by the server"`
+	Collapse bool   `json:"collapse,omitempty" jsonschema:"list near-duplicate tools as alternates of the best one"`
+
+	MaxTokens int  `json:"maxTokens,omitempty" jsonschema:"return only the results whose summaries fit in this many tokens"`
+	Trim      bool `json:"trim,omitempty" jsonschema:"with maxTokens, shorten the description of the first result that does not fit"`
+}
+
+// FacetsRequest is the input of /facets. An empty query counts the whole
+// catalog.
+type FacetsRequest struct {
+	Query string `json:"query,omitempty" jsonschema:"free-text query; empty counts the whole catalog"`
+	Limit int    `json:"limit,omitempty" jsonschema:"maximum number of values per facet; capped by the server"`
+}
+
+// ExplainRequest is the input of /explain.
+type ExplainRequest struct {
+	Query string `json:"query" jsonschema:"free-text query"`
+	Limit int    `json:"limit,omitempty" jsonschema:"maximum number of results; capped by the server"`
+	ID    string `json:"id,omitempty" jsonschema:"only explain the result with this tool ID"`
+}
+
+// SuggestRequest is the input of /suggest.
+type SuggestRequest struct {
+	Prefix string `json:"prefix" jsonschema:"prefix of a tool name, namespace or tag"`
+	Limit  int    `json:"limit,omitempty" jsonschema:"maximum number of suggestions; capped by the server"`
+}
+
+// SearchResponse is the output of /search and /explain.
+type SearchResponse struct {
+	Query string           `json:"query"`
+	Hits  []toolsearch.Hit `json:"hits"`
+	Stale bool             `json:"stale,omitempty"`
+
+	// With maxTokens, the tokens the hits take and the number of
+	// results left out.
+	TokensUsed int `json:"tokensUsed,omitempty"`
+	Dropped    int `json:"dropped,omitempty"`
+}
+
+// SuggestResponse is the output of /suggest.
+type SuggestResponse struct {
+	Prefix      string                  `json:"prefix"`
+	Suggestions []toolsearch.Suggestion `json:"suggestions"`
+}
+
+// FacetsResponse is the output of /facets.
+type FacetsResponse struct {
+	Query  string            `json:"query"`
+	Facets toolsearch.Facets `json:"facets"`
+}
+
+// ErrorResponse is the body of every error response.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// New returns an http.Handler serving searcher over the documents from docs.
+func New(searcher toolindex.Searcher, docs DocSource, opts Options) http.Handler {
+	if opts.DefaultLimit <= 0 {
+		opts.DefaultLimit = 10
+	}
+	if opts.MaxLimit <= 0 {
+		opts.MaxLimit = 100
+	}
+	if opts.MaxQueryLen <= 0 {
+		opts.MaxQueryLen = 256
+	}
+	if opts.MaxBodyBytes <= 0 {
+		opts.MaxBodyBytes = 64 << 10
+	}
+	if docs == nil {
+		docs = StaticDocs(nil)
+	}
+
+	h := &handler{searcher: searcher, docs: docs, opts: opts}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/search", h.search)
+	mux.HandleFunc("/suggest", h.suggest)
+	mux.HandleFunc("/facets", h.facets)
+	mux.HandleFunc("/explain", h.explain)
+	mux.HandleFunc("/openapi.json", h.openAPI)
+	return mux
+}
+
+type handler struct {
+	searcher toolindex.Searcher
+	docs     DocSource
+	opts     Options
+}
+
+// httpError is an error with the status code to report it under.
+type httpError struct {
+	status int
+	msg    string
+}
+
+func (e *httpError) Error() string { return e.msg }
+
+func badRequest(format string, args ...any) error {
+	return &httpError{status: http.StatusBadRequest, msg: fmt.Sprintf(format, args...)}
+}
+
+func (h *handler) search(w http.ResponseWriter, r *http.Request) {
+	var req SearchRequest
+	if err := h.decode(w, r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	limit, err := h.validate("query", req.Query, true, req.Limit)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if req.MaxTokens < 0 {
+		writeError(w, badRequest("maxTokens must not be negative"))
+		return
+	}
+	opts := toolsearch.SearchOptions{Collapse: req.Collapse}
+	if req.MaxTokens > 0 {
+		opts.Budget = &toolsearch.TokenBudget{MaxTokens: req.MaxTokens, TrimDescriptions: req.Trim}
+	}
+	result, err := h.run(r, req.Query, limit, h.docs(), opts)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, SearchResponse{Query: req.Query, Hits: result.Hits, Stale: result.Stale,
+		TokensUsed: result.TokensUsed, Dropped: result.Dropped})
+}
+
+func (h *handler) explain(w http.ResponseWriter, r *http.Request) {
+	var req ExplainRequest
+	if err := h.decode(w, r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	limit, err := h.validate("query", req.Query, true, req.Limit)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if _, ok := h.searcher.(ScoredSearcher); !ok {
+		writeError(w, &httpError{status: http.StatusNotImplemented, msg: "searcher does not support explanations"})
+		return
+	}
+	docs := h.docs()
+	if req.ID != "" {
+		// Search the whole catalog so the requested tool is found at any rank.
+		limit = len(docs)
+	}
+	result, err := h.run(r, req.Query, limit, docs, toolsearch.SearchOptions{Explain: true})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	hits := result.Hits
+	if req.ID != "" {
+		hits = []toolsearch.Hit{}
+		for _, hit := range result.Hits {
+			if hit.Summary.ID == req.ID {
+				hits = append(hits, hit)
+				break
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, SearchResponse{Query: req.Query, Hits: hits, Stale: result.Stale})
+}
+
+func (h *handler) facets(w http.ResponseWriter, r *http.Request) {
+	var req FacetsRequest
+	if err := h.decode(w, r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	limit, err := h.validate("query", req.Query, false, req.Limit)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	docs := h.docs()
+	result, err := h.run(r, req.Query, len(docs), docs, toolsearch.SearchOptions{})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	facets := toolsearch.ComputeFacets(result.Summaries())
+	facets.Namespaces = facets.Namespaces[:min(limit, len(facets.Namespaces))]
+	facets.Tags = facets.Tags[:min(limit, len(facets.Tags))]
+	writeJSON(w, http.StatusOK, FacetsResponse{Query: req.Query, Facets: facets})
+}
+
+func (h *handler) suggest(w http.ResponseWriter, r *http.Request) {
+	var req SuggestRequest
+	if err := h.decode(w, r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	limit, err := h.validate("prefix", req.Prefix, true, req.Limit)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	suggestions := toolsearch.Suggest(req.Prefix, limit, h.visibility(r).FilterDocs(h.docs()))
+	writeJSON(w, http.StatusOK, SuggestResponse{Prefix: req.Prefix, Suggestions: suggestions})
+}
+
+// run searches the current documents visible to the caller of r,
+// preferring scored search. opts is completed with the caller's
+// visibility, context and session.
+func (h *handler) run(r *http.Request, query string, limit int, docs []toolindex.SearchDoc, opts toolsearch.SearchOptions) (toolsearch.SearchResult, error) {
+	vis := h.visibility(r)
+	if s, ok := h.searcher.(ScoredSearcher); ok {
+		opts.Visibility = vis
+		opts.Context = r.Context()
+		opts.Session = h.session(r)
+		return s.SearchWithOptions(query, limit, docs, opts)
+	}
+	// Other searchers only see the visible docs, so limit still holds.
+	summaries, err := h.searcher.Search(query, limit, vis.FilterDocs(docs))
+	if err != nil {
+		return toolsearch.SearchResult{}, err
+	}
+	hits := make([]toolsearch.Hit, len(summaries))
+	for i, s := range summaries {
+		hits[i] = toolsearch.Hit{Summary: s}
+	}
+	return opts.Budget.Pack(toolsearch.SearchResult{Hits: hits}), nil
+}
+
+// visibility returns the visibility of the caller of r; nil shows every
+// tool.
+func (h *handler) visibility(r *http.Request) *toolsearch.Visibility {
+	if h.opts.Visibility == nil {
+		return nil
+	}
+	return h.opts.Visibility(r)
+}
+
+func (h *handler) session(r *http.Request) string {
+	if h.opts.Session == nil {
+		return ""
+	}
+	return h.opts.Session(r)
+}
+
+// validate checks the text field and limit of a request and returns the
+// effective limit.
+func (h *handler) validate(field, text string, required bool, limit int) (int, error) {
+	if required && strings.TrimSpace(text) == "" {
+		return 0, badRequest("%s is required", field)
+	}
+	if n := utf8.RuneCountInString(text); n > h.opts.MaxQueryLen {
+		return 0, badRequest("%s is %d characters; the maximum is %d", field, n, h.opts.MaxQueryLen)
+	}
+	if limit < 0 {
+		return 0, badRequest("limit must not be negative")
+	}
+	if limit == 0 {
+		return h.opts.DefaultLimit, nil
+	}
+	return min(limit, h.opts.MaxLimit), nil
+}
+
+// decode reads a request from the URL query of a GET or the JSON body of a
+// POST into v, a pointer to one of the request types.
+func (h *handler) decode(w http.ResponseWriter, r *http.Request, v any) error {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		return decodeQuery(r, v)
+	case http.MethodPost:
+		r.Body = http.MaxBytesReader(w, r.Body, int64(h.opts.MaxBodyBytes))
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(v); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				return &httpError{status: http.StatusRequestEntityTooLarge, msg: "request body too large"}
+			}
+			if errors.Is(err, io.EOF) {
+				return badRequest("request body is empty")
+			}
+			return badRequest("invalid JSON body: %v", err)
+		}
+		if dec.More() {
+			return badRequest("invalid JSON body: trailing data")
+		}
+		return nil
+	default:
+		w.Header().Set("Allow", "GET, HEAD, POST")
+		return &httpError{status: http.StatusMethodNotAllowed, msg: "method " + r.Method + " not allowed"}
+	}
+}
+
+func decodeQuery(r *http.Request, v any) error {
+	q := r.URL.Query()
+	limit := 0
+	if s := q.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return badRequest("limit must be an integer")
+		}
+		limit = n
+	}
+	switch req := v.(type) {
+	case *SearchRequest:
+		maxTokens := 0
+		if s := q.Get("maxTokens"); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				return badRequest("maxTokens must be an integer")
+			}
+			maxTokens = n
+		}
+		*req = SearchRequest{Query: q.Get("query"), Limit: limit, Collapse: q.Get("collapse") == "true",
+			MaxTokens: maxTokens, Trim: q.Get("trim") == "true"}
+	case *FacetsRequest:
+		*req = FacetsRequest{Query: q.Get("query"), Limit: limit}
+	case *ExplainRequest:
+		*req = ExplainRequest{Query: q.Get("query"), Limit: limit, ID: q.Get("id")}
+	case *SuggestRequest:
+		*req = SuggestRequest{Prefix: q.Get("prefix"), Limit: limit}
+	default:
+		return fmt.Errorf("unsupported request type %T", v)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var he *httpError
+	if errors.As(err, &he) {
+		status = he.status
+	}
+	writeJSON(w, status, ErrorResponse{Error: err.Error()})
+}
@@ -0,0 +1,393 @@
+package httpapi_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jonwraymond/toolindex"
+	"github.com/jonwraymond/toolsearch"
+	"github.com/jonwraymond/toolsearch/httpapi"
+)
+
+func testDocs() []toolindex.SearchDoc {
+	tools := []struct{ name, desc, ns string }{
+		{"git_status", "Show the working tree status", "git"},
+		{"git_commit", "Record changes to the repository", "git"},
+		{"docker_ps", "List running containers", "docker"},
+		{"kubectl_get", "Display one or many resources", "kubectl"},
+	}
+	docs := make([]toolindex.SearchDoc, len(tools))
+	for i, tt := range tools {
+		id := tt.ns + ":" + tt.name
+		docs[i] = toolindex.SearchDoc{
+			ID:      id,
+			DocText: strings.ToLower(tt.name + " " + tt.ns + " " + tt.desc),
+			Summary: toolindex.Summary{ID: id, Name: tt.name, Namespace: tt.ns, ShortDescription: tt.desc, Tags: []string{tt.ns}},
+		}
+	}
+	return docs
+}
+
+func newServer(t *testing.T, searcher toolindex.Searcher, opts httpapi.Options) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(httpapi.New(searcher, httpapi.StaticDocs(testDocs()), opts))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newBM25Server(t *testing.T, opts httpapi.Options) *httptest.Server {
+	t.Helper()
+	s := toolsearch.NewBM25Searcher(toolsearch.BM25Config{})
+	t.Cleanup(func() { _ = s.Close() })
+	return newServer(t, s, opts)
+}
+
+// do sends a request and decodes the JSON response into out, returning
+// the status code.
+func do(t *testing.T, method, url, body string, out any) int {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, url, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if ct := resp.Header.Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func TestSearch_GetAndPost(t *testing.T) {
+	srv := newBM25Server(t, httpapi.Options{})
+
+	var got httpapi.SearchResponse
+	if code := do(t, http.MethodGet, srv.URL+"/search?query=containers&limit=2", "", &got); code != http.StatusOK {
+		t.Fatalf("GET status = %d", code)
+	}
+	if len(got.Hits) == 0 || got.Hits[0].Summary.ID != "docker:docker_ps" {
+		t.Fatalf("unexpected hits: %+v", got.Hits)
+	}
+	if got.Hits[0].Score <= 0 {
+		t.Errorf("expected a positive score, got %v", got.Hits[0].Score)
+	}
+
+	var post httpapi.SearchResponse
+	if code := do(t, http.MethodPost, srv.URL+"/search", `{"query": "containers", "limit": 2}`, &post); code != http.StatusOK {
+		t.Fatalf("POST status = %d", code)
+	}
+	if len(post.Hits) != len(got.Hits) || post.Hits[0].Summary.ID != got.Hits[0].Summary.ID {
+		t.Errorf("POST hits %+v differ from GET hits %+v", post.Hits, got.Hits)
+	}
+}
+
+func TestSearch_Validation(t *testing.T) {
+	srv := newBM25Server(t, httpapi.Options{MaxQueryLen: 8})
+
+	cases := []struct {
+		name, method, path, body string
+		want                     int
+	}{
+		{"missing query", http.MethodGet, "/search", "", http.StatusBadRequest},
+		{"query too long", http.MethodGet, "/search?query=abcdefghij", "", http.StatusBadRequest},
+		{"bad limit", http.MethodGet, "/search?query=git&limit=ten", "", http.StatusBadRequest},
+		{"negative limit", http.MethodGet, "/search?query=git&limit=-1", "", http.StatusBadRequest},
+		{"unknown field", http.MethodPost, "/search", `{"query": "git", "size": 3}`, http.StatusBadRequest},
+		{"malformed body", http.MethodPost, "/search", `{"query":`, http.StatusBadRequest},
+		{"empty body", http.MethodPost, "/search", ``, http.StatusBadRequest},
+		{"method", http.MethodDelete, "/search?query=git", "", http.StatusMethodNotAllowed},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got httpapi.ErrorResponse
+			if code := do(t, tc.method, srv.URL+tc.path, tc.body, &got); code != tc.want {
+				t.Errorf("status = %d, want %d", code, tc.want)
+			}
+			if got.Error == "" {
+				t.Error("expected an error message")
+			}
+		})
+	}
+}
+
+func TestSearch_LimitCaps(t *testing.T) {
+	srv := newBM25Server(t, httpapi.Options{DefaultLimit: 1, MaxLimit: 2})
+
+	var got httpapi.SearchResponse
+	do(t, http.MethodGet, srv.URL+"/search?query=git", "", &got)
+	if len(got.Hits) != 1 {
+		t.Errorf("default limit: got %d hits, want 1", len(got.Hits))
+	}
+	do(t, http.MethodGet, srv.URL+"/search?query=git+docker+kubectl&limit=50", "", &got)
+	if len(got.Hits) != 2 {
+		t.Errorf("capped limit: got %d hits, want 2", len(got.Hits))
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	srv := newBM25Server(t, httpapi.Options{})
+
+	var got httpapi.SuggestResponse
+	if code := do(t, http.MethodGet, srv.URL+"/suggest?prefix=gi", "", &got); code != http.StatusOK {
+		t.Fatalf("status = %d", code)
+	}
+	if len(got.Suggestions) == 0 || got.Suggestions[0].Text != "git" || got.Suggestions[0].Count != 2 {
+		t.Errorf("unexpected suggestions: %+v", got.Suggestions)
+	}
+	if code := do(t, http.MethodGet, srv.URL+"/suggest", "", nil); code != http.StatusBadRequest {
+		t.Errorf("missing prefix: status = %d, want 400", code)
+	}
+}
+
+func TestFacets(t *testing.T) {
+	srv := newBM25Server(t, httpapi.Options{})
+
+	var got httpapi.FacetsResponse
+	if code := do(t, http.MethodPost, srv.URL+"/facets", `{}`, &got); code != http.StatusOK {
+		t.Fatalf("status = %d", code)
+	}
+	if len(got.Facets.Namespaces) != 3 || got.Facets.Namespaces[0] != (toolsearch.FacetCount{Value: "git", Count: 2}) {
+		t.Errorf("unexpected namespace facets: %+v", got.Facets.Namespaces)
+	}
+
+	do(t, http.MethodGet, srv.URL+"/facets?query=containers", "", &got)
+	if len(got.Facets.Namespaces) != 1 || got.Facets.Namespaces[0].Value != "docker" {
+		t.Errorf("unexpected facets for query: %+v", got.Facets.Namespaces)
+	}
+}
+
+func TestExplain(t *testing.T) {
+	srv := newBM25Server(t, httpapi.Options{})
+
+	var got httpapi.SearchResponse
+	if code := do(t, http.MethodGet, srv.URL+"/explain?query=git&id=git:git_commit", "", &got); code != http.StatusOK {
+		t.Fatalf("status = %d", code)
+	}
+	if len(got.Hits) != 1 || got.Hits[0].Summary.ID != "git:git_commit" {
+		t.Fatalf("unexpected hits: %+v", got.Hits)
+	}
+	if got.Hits[0].Explanation == nil || got.Hits[0].Explanation.Value != got.Hits[0].Score {
+		t.Errorf("expected an explanation matching the score, got %+v", got.Hits[0].Explanation)
+	}
+}
+
+// plainSearcher is a toolindex.Searcher without scores.
+type plainSearcher struct{}
+
+func (plainSearcher) Search(_ string, limit int, docs []toolindex.SearchDoc) ([]toolindex.Summary, error) {
+	var out []toolindex.Summary
+	for _, d := range docs[:min(limit, len(docs))] {
+		out = append(out, d.Summary)
+	}
+	return out, nil
+}
+
+func TestPlainSearcher(t *testing.T) {
+	srv := newServer(t, plainSearcher{}, httpapi.Options{})
+
+	var got httpapi.SearchResponse
+	if code := do(t, http.MethodGet, srv.URL+"/search?query=anything&limit=3", "", &got); code != http.StatusOK {
+		t.Fatalf("status = %d", code)
+	}
+	if len(got.Hits) != 3 || got.Hits[0].Score != 0 {
+		t.Errorf("unexpected hits: %+v", got.Hits)
+	}
+	if code := do(t, http.MethodGet, srv.URL+"/explain?query=anything", "", nil); code != http.StatusNotImplemented {
+		t.Errorf("explain status = %d, want 501", code)
+	}
+}
+
+func TestOpenAPI(t *testing.T) {
+	srv := newBM25Server(t, httpapi.Options{})
+
+	var doc struct {
+		OpenAPI    string                    `json:"openapi"`
+		Paths      map[string]map[string]any `json:"paths"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	if code := do(t, http.MethodGet, srv.URL+"/openapi.json", "", &doc); code != http.StatusOK {
+		t.Fatalf("status = %d", code)
+	}
+	if doc.OpenAPI != "3.1.0" {
+		t.Errorf("openapi = %q, want 3.1.0", doc.OpenAPI)
+	}
+	for _, path := range []string{"/search", "/suggest", "/facets", "/explain"} {
+		ops, ok := doc.Paths[path]
+		if !ok {
+			t.Errorf("missing path %s", path)
+			continue
+		}
+		if _, ok := ops["get"]; !ok {
+			t.Errorf("%s: missing get operation", path)
+		}
+		if _, ok := ops["post"]; !ok {
+			t.Errorf("%s: missing post operation", path)
+		}
+	}
+	for _, name := range []string{"SearchRequest", "SearchResponse", "SuggestResponse", "FacetsResponse", "ErrorResponse"} {
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			t.Errorf("missing schema %s", name)
+		}
+	}
+}
+
+func TestVisibility(t *testing.T) {
+	byTenant := map[string]*toolsearch.Visibility{
+		"ops": {Namespaces: []string{"docker", "kubectl"}},
+	}
+	opts := httpapi.Options{Visibility: func(r *http.Request) *toolsearch.Visibility {
+		return byTenant[r.Header.Get("X-Tenant")]
+	}}
+	for name, searcher := range map[string]toolindex.Searcher{
+		"bm25":  toolsearch.NewBM25Searcher(toolsearch.BM25Config{}),
+		"plain": plainSearcher{},
+	} {
+		t.Run(name, func(t *testing.T) {
+			h := httpapi.New(searcher, httpapi.StaticDocs(testDocs()), opts)
+			get := func(url string, out any) {
+				t.Helper()
+				req := httptest.NewRequest(http.MethodGet, url, nil)
+				req.Header.Set("X-Tenant", "ops")
+				rec := httptest.NewRecorder()
+				h.ServeHTTP(rec, req)
+				if rec.Code != http.StatusOK {
+					t.Fatalf("GET %s: status = %d", url, rec.Code)
+				}
+				if err := json.NewDecoder(rec.Body).Decode(out); err != nil {
+					t.Fatalf("decode response: %v", err)
+				}
+			}
+
+			var search httpapi.SearchResponse
+			get("/search?query=git+status+containers+resources&limit=2", &search)
+			if len(search.Hits) != 2 {
+				t.Errorf("got %d hits, want a full page of visible tools", len(search.Hits))
+			}
+			for _, hit := range search.Hits {
+				if hit.Summary.Namespace == "git" {
+					t.Errorf("hidden tool %s leaked into search", hit.Summary.ID)
+				}
+			}
+
+			var facets httpapi.FacetsResponse
+			get("/facets", &facets)
+			for _, f := range facets.Facets.Namespaces {
+				if f.Value == "git" {
+					t.Errorf("hidden namespace leaked into facets: %+v", facets.Facets.Namespaces)
+				}
+			}
+
+			var suggest httpapi.SuggestResponse
+			get("/suggest?prefix=gi", &suggest)
+			if len(suggest.Suggestions) != 0 {
+				t.Errorf("hidden tools leaked into suggestions: %+v", suggest.Suggestions)
+			}
+		})
+	}
+}
+
+func TestSession(t *testing.T) {
+	var buf bytes.Buffer
+	log := toolsearch.NewQueryLogger(&buf, toolsearch.QueryLogOptions{})
+	searcher := toolsearch.NewBM25Searcher(toolsearch.BM25Config{QueryLog: log})
+	h := httpapi.New(searcher, httpapi.StaticDocs(testDocs()), httpapi.Options{
+		Session: func(r *http.Request) string { return r.Header.Get("X-Session") },
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/search?query=status", nil)
+	req.Header.Set("X-Session", "conv-1")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	if err := log.Flush(); err != nil {
+		t.Fatalf("Flush error: %v", err)
+	}
+	records, err := toolsearch.ReadQueryLog(&buf)
+	if err != nil {
+		t.Fatalf("ReadQueryLog error: %v", err)
+	}
+	if len(records) != 1 || records[0].Session != "conv-1" || records[0].Query != "status" {
+		t.Errorf("records = %+v, want one search in session conv-1", records)
+	}
+}
+
+func TestSearch_Collapse(t *testing.T) {
+	docs := testDocs()
+	docs = append(docs, toolindex.SearchDoc{
+		ID:      "gitmcp:status",
+		DocText: "status gitmcp show the working tree status",
+		Summary: toolindex.Summary{ID: "gitmcp:status", Name: "status", Namespace: "gitmcp", ShortDescription: "Show the working tree status"},
+	})
+	s := toolsearch.NewBM25Searcher(toolsearch.BM25Config{})
+	t.Cleanup(func() { _ = s.Close() })
+	srv := httptest.NewServer(httpapi.New(s, httpapi.StaticDocs(docs), httpapi.Options{}))
+	t.Cleanup(srv.Close)
+
+	var plain, collapsed httpapi.SearchResponse
+	do(t, http.MethodGet, srv.URL+"/search?query=working+tree+status", "", &plain)
+	if len(plain.Hits) != 2 {
+		t.Fatalf("hits = %+v, want both status tools", plain.Hits)
+	}
+	for _, req := range []struct{ method, url, body string }{
+		{http.MethodGet, srv.URL + "/search?query=working+tree+status&collapse=true", ""},
+		{http.MethodPost, srv.URL + "/search", `{"query": "working tree status", "collapse": true}`},
+	} {
+		if code := do(t, req.method, req.url, req.body, &collapsed); code != http.StatusOK {
+			t.Fatalf("%s status = %d", req.method, code)
+		}
+		if len(collapsed.Hits) != 1 || len(collapsed.Hits[0].Alternates) != 1 || collapsed.Hits[0].Alternates[0].ID != plain.Hits[1].Summary.ID {
+			t.Errorf("%s collapsed hits = %+v", req.method, collapsed.Hits)
+		}
+	}
+}
+
+func TestSearch_TokenBudget(t *testing.T) {
+	s := toolsearch.NewBM25Searcher(toolsearch.BM25Config{})
+	t.Cleanup(func() { _ = s.Close() })
+	srv := httptest.NewServer(httpapi.New(s, httpapi.StaticDocs(testDocs()), httpapi.Options{}))
+	t.Cleanup(srv.Close)
+
+	var full httpapi.SearchResponse
+	do(t, http.MethodGet, srv.URL+"/search?query=git", "", &full)
+	if len(full.Hits) < 2 || full.TokensUsed != 0 {
+		t.Fatalf("unbudgeted response = %+v", full)
+	}
+	for _, req := range []struct{ method, url, body string }{
+		{http.MethodGet, srv.URL + "/search?query=git&maxTokens=70", ""},
+		{http.MethodPost, srv.URL + "/search", `{"query": "git", "maxTokens": 70}`},
+	} {
+		var packed httpapi.SearchResponse
+		if code := do(t, req.method, req.url, req.body, &packed); code != http.StatusOK {
+			t.Fatalf("%s status = %d", req.method, code)
+		}
+		if len(packed.Hits) == 0 || packed.Dropped == 0 || packed.TokensUsed > 70 || len(packed.Hits)+packed.Dropped != len(full.Hits) {
+			t.Errorf("%s packed response = %+v", req.method, packed)
+		}
+	}
+
+	for _, req := range []struct{ method, url, body string }{
+		{http.MethodGet, srv.URL + "/search?query=git&maxTokens=lots", ""},
+		{http.MethodGet, srv.URL + "/search?query=git&maxTokens=-1", ""},
+		{http.MethodPost, srv.URL + "/search", `{"query": "git", "maxTokens": -1}`},
+	} {
+		if code := do(t, req.method, req.url, req.body, nil); code != http.StatusBadRequest {
+			t.Errorf("%s %s status = %d, want 400", req.method, req.url, code)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"reflect"
+	"slices"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/jonwraymond/toolsearch"
+)
+
+// endpoint describes one operation for the OpenAPI document.
+type endpoint struct {
+	path     string
+	summary  string
+	request  reflect.Type
+	response reflect.Type
+}
+
+var endpoints = []endpoint{
+	{"/search", "Rank tools for a query", reflect.TypeFor[SearchRequest](), reflect.TypeFor[SearchResponse]()},
+	{"/suggest", "Complete a tool name, namespace or tag prefix", reflect.TypeFor[SuggestRequest](), reflect.TypeFor[SuggestResponse]()},
+	{"/facets", "Count namespaces and tags of the tools matching a query", reflect.TypeFor[FacetsRequest](), reflect.TypeFor[FacetsResponse]()},
+	{"/explain", "Rank tools for a query with scoring explanations", reflect.TypeFor[ExplainRequest](), reflect.TypeFor[SearchResponse]()},
+}
+
+// OpenAPI returns the OpenAPI 3.1 document describing the handler. Request
+// and response schemas are generated from the Go types in this package.
+func OpenAPI() ([]byte, error) {
+	// Explanation is recursive, which schema inference rejects; describe
+	// it as an open object instead.
+	opts := &jsonschema.ForOptions{TypeSchemas: map[reflect.Type]*jsonschema.Schema{
+		reflect.TypeFor[toolsearch.Explanation](): {
+			Type:        "object",
+			Description: "scoring explanation tree: value, message and children",
+		},
+	}}
+
+	schemas := map[string]*jsonschema.Schema{}
+	ref := func(t reflect.Type) (map[string]any, error) {
+		if _, ok := schemas[t.Name()]; !ok {
+			s, err := jsonschema.ForType(t, opts)
+			if err != nil {
+				return nil, err
+			}
+			schemas[t.Name()] = s
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + t.Name()}, nil
+	}
+	errRef, err := ref(reflect.TypeFor[ErrorResponse]())
+	if err != nil {
+		return nil, err
+	}
+	errorResponse := map[string]any{
+		"description": "error",
+		"content":     map[string]any{"application/json": map[string]any{"schema": errRef}},
+	}
+
+	paths := map[string]any{}
+	for _, ep := range endpoints {
+		reqRef, err := ref(ep.request)
+		if err != nil {
+			return nil, err
+		}
+		respRef, err := ref(ep.response)
+		if err != nil {
+			return nil, err
+		}
+		responses := map[string]any{
+			"200": map[string]any{
+				"description": "success",
+				"content":     map[string]any{"application/json": map[string]any{"schema": respRef}},
+			},
+			"4XX": errorResponse,
+			"5XX": errorResponse,
+		}
+		paths[ep.path] = map[string]any{
+			"get": map[string]any{
+				"summary":    ep.summary,
+				"parameters": queryParameters(schemas[ep.request.Name()]),
+				"responses":  responses,
+			},
+			"post": map[string]any{
+				"summary": ep.summary,
+				"requestBody": map[string]any{
+					"required": true,
+					"content":  map[string]any{"application/json": map[string]any{"schema": reqRef}},
+				},
+				"responses": responses,
+			},
+		}
+	}
+
+	doc := map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":   "toolsearch",
+			"version": "1",
+		},
+		"paths":      paths,
+		"components": map[string]any{"schemas": schemas},
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// queryParameters describes the properties of a request schema as GET
+// query parameters.
+func queryParameters(s *jsonschema.Schema) []map[string]any {
+	required := make(map[string]bool, len(s.Required))
+	for _, name := range s.Required {
+		required[name] = true
+	}
+	var params []map[string]any
+	for _, name := range slices.Sorted(maps.Keys(s.Properties)) {
+		prop := s.Properties[name]
+		params = append(params, map[string]any{
+			"name":        name,
+			"in":          "query",
+			"required":    required[name],
+			"description": prop.Description,
+			"schema":      map[string]any{"type": prop.Type},
+		})
+	}
+	return params
+}
+
+func (h *handler) openAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeError(w, &httpError{status: http.StatusMethodNotAllowed, msg: "method " + r.Method + " not allowed"})
+		return
+	}
+	doc, err := OpenAPI()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(doc)
+}
@@ -0,0 +1,142 @@
+// Package catalog loads tool catalogs for the toolsearch commands.
+//
+// A catalog is a JSON array or newline-delimited JSON stream whose entries
+// are either toolmodel.Tool definitions or toolindex.SearchDoc values. The
+// two forms may be mixed; SearchDoc entries are recognized by their
+// "DocText" or "Summary" field.
+package catalog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jonwraymond/toolindex"
+	"github.com/jonwraymond/toolmodel"
+	"github.com/jonwraymond/toolsearch"
+)
+
+// Catalog holds the entries of a loaded catalog.
+type Catalog struct {
+	// Tools holds entries given as toolmodel.Tool, in input order.
+	Tools []toolmodel.Tool
+	// Docs holds a search document for every entry, in input order. Tool
+	// entries are converted with toolsearch.SearchDocFromTool.
+	Docs []toolindex.SearchDoc
+}
+
+// Load reads a catalog from path, or from stdin when path is "-".
+func Load(path string, stdin io.Reader) (Catalog, error) {
+	if path == "-" {
+		return Read(stdin)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return Catalog{}, err
+	}
+	defer func() { _ = f.Close() }()
+	c, err := Read(f)
+	if err != nil {
+		return Catalog{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
+
+// Read decodes a catalog from r, accepting a JSON array or JSONL.
+func Read(r io.Reader) (Catalog, error) {
+	br := bufio.NewReader(r)
+	first, err := peekNonSpace(br)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return Catalog{}, nil
+		}
+		return Catalog{}, err
+	}
+
+	var c Catalog
+	if first == '[' {
+		var entries []json.RawMessage
+		if err := json.NewDecoder(br).Decode(&entries); err != nil {
+			return Catalog{}, fmt.Errorf("decode catalog: %w", err)
+		}
+		for i, raw := range entries {
+			if err := c.add(raw); err != nil {
+				return Catalog{}, fmt.Errorf("entry %d: %w", i, err)
+			}
+		}
+		return c, nil
+	}
+
+	scanner := bufio.NewScanner(br)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		if err := c.add(text); err != nil {
+			return Catalog{}, fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Catalog{}, fmt.Errorf("read catalog: %w", err)
+	}
+	return c, nil
+}
+
+// add decodes one entry, detecting whether it is a SearchDoc or a Tool.
+func (c *Catalog) add(raw []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return err
+	}
+	for key := range fields {
+		switch strings.ToLower(key) {
+		case "doctext", "summary":
+			var doc toolindex.SearchDoc
+			if err := json.Unmarshal(raw, &doc); err != nil {
+				return err
+			}
+			if doc.ID == "" {
+				doc.ID = doc.Summary.ID
+			}
+			if doc.ID == "" {
+				return errors.New("search doc has no ID")
+			}
+			c.Docs = append(c.Docs, doc)
+			return nil
+		}
+	}
+
+	tool, err := toolmodel.FromJSON(raw)
+	if err != nil {
+		return err
+	}
+	if err := tool.Validate(); err != nil {
+		return err
+	}
+	c.Tools = append(c.Tools, *tool)
+	c.Docs = append(c.Docs, toolsearch.SearchDocFromTool(*tool))
+	return nil
+}
+
+func peekNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return b, br.UnreadByte()
+	}
+}
@@ -0,0 +1,71 @@
+package catalog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRead_JSONArrayOfTools(t *testing.T) {
+	input := `[
+  {"name": "read_file", "description": "Read a file", "inputSchema": {"type": "object"}, "namespace": "fs", "tags": ["Files"]},
+  {"name": "write_file", "description": "Write a file", "inputSchema": {"type": "object"}, "namespace": "fs"}
+]`
+	c, err := Read(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Read error: %v", err)
+	}
+	if len(c.Tools) != 2 || len(c.Docs) != 2 {
+		t.Fatalf("got %d tools, %d docs; want 2, 2", len(c.Tools), len(c.Docs))
+	}
+	doc := c.Docs[0]
+	if doc.ID != "fs:read_file" || doc.Summary.Tags[0] != "files" {
+		t.Errorf("unexpected doc: %+v", doc)
+	}
+	if !strings.Contains(doc.DocText, "read a file") {
+		t.Errorf("DocText not built from tool: %q", doc.DocText)
+	}
+}
+
+func TestRead_JSONLMixed(t *testing.T) {
+	input := `
+{"ID": "a:b", "DocText": "alpha", "Summary": {"id": "a:b", "name": "b"}}
+
+{"name": "c", "inputSchema": {"type": "object"}}
+{"docText": "beta", "summary": {"id": "x:y", "name": "y"}}
+`
+	c, err := Read(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Read error: %v", err)
+	}
+	if len(c.Docs) != 3 || len(c.Tools) != 1 {
+		t.Fatalf("got %d docs, %d tools; want 3, 1", len(c.Docs), len(c.Tools))
+	}
+	if c.Docs[1].ID != "c" || c.Docs[2].ID != "x:y" {
+		t.Errorf("unexpected doc IDs: %s, %s", c.Docs[1].ID, c.Docs[2].ID)
+	}
+}
+
+func TestRead_Errors(t *testing.T) {
+	cases := map[string]string{
+		"invalid tool":   `{"name": "no schema"}`,
+		"doc without id": `{"DocText": "x"}`,
+		"malformed":      "{\"name\": \"ok\", \"inputSchema\": {}}\nnot json",
+	}
+	for name, input := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Read(strings.NewReader(input)); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestRead_Empty(t *testing.T) {
+	c, err := Read(strings.NewReader("  \n"))
+	if err != nil {
+		t.Fatalf("Read error: %v", err)
+	}
+	if len(c.Docs) != 0 {
+		t.Errorf("expected empty catalog, got %d docs", len(c.Docs))
+	}
+}
@@ -0,0 +1,98 @@
+// Package cluster groups near-duplicate documents by the Jaccard
+// similarity of their word sets, for FindDuplicates and the catalog
+// linter.
+package cluster
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+)
+
+// Similar groups n documents linked by pairs whose word sets have a
+// Jaccard similarity of at least threshold, a value in (0, 1]. words
+// returns the distinct words of document i; Similar may reorder the
+// returned slice.
+//
+// Groups of two or more documents are returned, each holding indexes in
+// ascending order, ordered by their first index. Only pairs sharing one
+// of their rarest words are compared (a prefix filter, which never misses
+// a pair), so large catalogs cluster in about linear time.
+func Similar(n int, words func(i int) []string, threshold float64) [][]int {
+	sets := make([][]string, n)
+	df := make(map[string]int)
+	for i := range sets {
+		sets[i] = words(i)
+		for _, w := range sets[i] {
+			df[w]++
+		}
+	}
+
+	parent := make([]int, n)
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	// A set x can only reach similarity t with a set sharing one of its
+	// first |x| - ceil(t|x|) + 1 words in a common order, rarest first.
+	index := make(map[string][]int)
+	for i, set := range sets {
+		if len(set) == 0 {
+			continue
+		}
+		slices.SortFunc(set, func(a, b string) int { return cmp.Or(cmp.Compare(df[a], df[b]), strings.Compare(a, b)) })
+		prefix := min(len(set)-int(math.Ceil(threshold*float64(len(set))-1e-9))+1, len(set))
+		compared := make(map[int]bool)
+		for _, w := range set[:prefix] {
+			for _, j := range index[w] {
+				if !compared[j] {
+					compared[j] = true
+					if Jaccard(set, sets[j]) >= threshold {
+						parent[find(i)] = find(j)
+					}
+				}
+			}
+			index[w] = append(index[w], i)
+		}
+	}
+
+	members := make(map[int][]int)
+	var roots []int
+	for i := range n {
+		root := find(i)
+		if members[root] == nil {
+			roots = append(roots, root)
+		}
+		members[root] = append(members[root], i)
+	}
+	var groups [][]int
+	for _, root := range roots {
+		if len(members[root]) > 1 {
+			groups = append(groups, members[root])
+		}
+	}
+	return groups
+}
+
+// Jaccard returns the Jaccard similarity of two sets of distinct words.
+func Jaccard(a, b []string) float64 {
+	set := make(map[string]bool, len(a))
+	for _, w := range a {
+		set[w] = true
+	}
+	shared := 0
+	for _, w := range b {
+		if set[w] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
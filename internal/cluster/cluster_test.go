@@ -0,0 +1,26 @@
+package cluster
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSimilar(t *testing.T) {
+	docs := []string{
+		"create issue repo",
+		"list pods",
+		"create issue repository",
+		"create issue repo",
+		"list pods namespace",
+		"",
+	}
+	words := func(i int) []string { return strings.Fields(docs[i]) }
+
+	if got, want := Similar(len(docs), words, 0.5), [][]int{{0, 2, 3}, {1, 4}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Similar at 0.5 = %v, want %v", got, want)
+	}
+	if got, want := Similar(len(docs), words, 1), [][]int{{0, 3}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Similar at 1 = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,188 @@
+package toolsearch
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/jonwraymond/toolindex"
+)
+
+// ErrNoSelections is returned by LearnBoosts when no selection record
+// refers to a tool present in the document set.
+var ErrNoSelections = errors.New("no usable selection records")
+
+// SelectionRecord is one logged search interaction: the query an agent
+// issued, the tool IDs it was shown, and the tool it went on to select.
+type SelectionRecord struct {
+	Query    string   `json:"query"`
+	Shown    []string `json:"shown,omitempty"`
+	Selected string   `json:"selected"`
+}
+
+// ReadSelectionLog decodes newline-delimited JSON selection records.
+// Blank lines are skipped; a malformed line fails with its line number.
+func ReadSelectionLog(r io.Reader) ([]SelectionRecord, error) {
+	var records []SelectionRecord
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var rec SelectionRecord
+		if err := json.Unmarshal([]byte(text), &rec); err != nil {
+			return nil, fmt.Errorf("selection log line %d: %w", line, err)
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read selection log: %w", err)
+	}
+	return records, nil
+}
+
+// LearnOptions configures LearnBoosts.
+type LearnOptions struct {
+	// Base supplies the non-boost settings (MaxDocs, MaxDocTextLen) used for
+	// every candidate, and is the baseline the learned config is compared to.
+	// Candidates are scored without its QueryLog, Observer, cache and
+	// async rebuilds, which the returned Config keeps.
+	Base BM25Config
+
+	// MaxBoost is the largest value tried for each boost (default 6).
+	MaxBoost int
+
+	// Cutoff is the rank cutoff for reciprocal rank (default 10). A selected
+	// tool ranked below the cutoff contributes zero.
+	Cutoff int
+}
+
+// LearnResult reports the best configuration found by LearnBoosts.
+type LearnResult struct {
+	Config BM25Config // recommended configuration
+
+	MRR         float64 // mean reciprocal rank of Config
+	BaselineMRR float64 // mean reciprocal rank of LearnOptions.Base
+	LoggedMRR   float64 // mean reciprocal rank of selections within Shown
+
+	Records   int // records that contributed to the metrics
+	Skipped   int // records with an empty query or unknown selected tool
+	Evaluated int // candidate configurations scored
+}
+
+// LearnBoosts searches the field boost space for the configuration that
+// maximizes mean reciprocal rank of the selected tool over the given
+// selection records.
+//
+// Every combination of NameBoost, NamespaceBoost and TagsBoost in
+// [1, MaxBoost] is scored against docs. Ties keep the baseline, then the
+// earliest candidate in (name, namespace, tags) order, so the result is
+// deterministic. BM25 k1 and b are fixed by Bleve and are not searched.
+func LearnBoosts(docs []toolindex.SearchDoc, records []SelectionRecord, opts LearnOptions) (LearnResult, error) {
+	if opts.MaxBoost <= 0 {
+		opts.MaxBoost = 6
+	}
+	if opts.Cutoff <= 0 {
+		opts.Cutoff = 10
+	}
+
+	known := make(map[string]struct{}, len(docs))
+	for _, doc := range docs {
+		known[doc.ID] = struct{}{}
+	}
+
+	// Group usable records by query so each candidate runs a query once.
+	var result LearnResult
+	selections := make(map[string][]string)
+	var loggedSum float64
+	for _, rec := range records {
+		query := strings.TrimSpace(rec.Query)
+		if _, ok := known[rec.Selected]; !ok || query == "" {
+			result.Skipped++
+			continue
+		}
+		selections[query] = append(selections[query], rec.Selected)
+		if pos := slices.Index(rec.Shown, rec.Selected); pos >= 0 {
+			loggedSum += 1 / float64(pos+1)
+		}
+		result.Records++
+	}
+	if result.Records == 0 {
+		return LearnResult{}, ErrNoSelections
+	}
+	queries := make([]string, 0, len(selections))
+	for q := range selections {
+		queries = append(queries, q)
+	}
+	slices.Sort(queries)
+
+	score := func(cfg BM25Config) (float64, error) {
+		// Scoring searches are not traffic: keep them out of the query
+		// log and observer. Each candidate gets a fresh searcher that runs
+		// every query once over one catalog, so a cache only costs memory
+		// and there is nothing to rebuild in the background.
+		cfg.QueryLog, cfg.Observer = nil, nil
+		cfg.CacheSize, cfg.AsyncRebuild = 0, false
+		s := NewBM25Searcher(cfg)
+		defer func() { _ = s.Close() }()
+
+		var sum float64
+		for _, q := range queries {
+			results, err := s.Search(q, opts.Cutoff, docs)
+			if err != nil {
+				return 0, fmt.Errorf("search %q: %w", q, err)
+			}
+			for _, selected := range selections[q] {
+				for i, r := range results {
+					if r.ID == selected {
+						sum += 1 / float64(i+1)
+						break
+					}
+				}
+			}
+		}
+		return sum / float64(result.Records), nil
+	}
+
+	base := NewBM25Searcher(opts.Base).cfg
+	baseMRR, err := score(base)
+	if err != nil {
+		return LearnResult{}, err
+	}
+	result.Config = base
+	result.MRR = baseMRR
+	result.BaselineMRR = baseMRR
+	result.LoggedMRR = loggedSum / float64(result.Records)
+	result.Evaluated = 1
+
+	for name := 1; name <= opts.MaxBoost; name++ {
+		for ns := 1; ns <= opts.MaxBoost; ns++ {
+			for tags := 1; tags <= opts.MaxBoost; tags++ {
+				if name == base.NameBoost && ns == base.NamespaceBoost && tags == base.TagsBoost {
+					continue
+				}
+				cfg := base
+				cfg.NameBoost, cfg.NamespaceBoost, cfg.TagsBoost = name, ns, tags
+				mrr, err := score(cfg)
+				if err != nil {
+					return LearnResult{}, err
+				}
+				result.Evaluated++
+				if mrr > result.MRR {
+					result.Config = cfg
+					result.MRR = mrr
+				}
+			}
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,177 @@
+package toolsearch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jonwraymond/toolindex"
+)
+
+func makeLearnDocs() []toolindex.SearchDoc {
+	return []toolindex.SearchDoc{
+		{
+			ID:      "ops:deploy",
+			DocText: "ship a release",
+			Summary: toolindex.Summary{ID: "ops:deploy", Name: "deploy", Namespace: "ops", Tags: []string{"release"}},
+		},
+		{
+			ID:      "ops:history",
+			DocText: "deploy deploy deploy deploy deploy deploy deploy deploy log",
+			Summary: toolindex.Summary{ID: "ops:history", Name: "history", Namespace: "ops", Tags: []string{"audit"}},
+		},
+	}
+}
+
+func TestReadSelectionLog(t *testing.T) {
+	log := `{"query":"deploy","shown":["ops:history","ops:deploy"],"selected":"ops:deploy"}
+
+{"query":"history","selected":"ops:history"}
+`
+	records, err := ReadSelectionLog(strings.NewReader(log))
+	if err != nil {
+		t.Fatalf("ReadSelectionLog error: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2", len(records))
+	}
+	if records[0].Selected != "ops:deploy" || len(records[0].Shown) != 2 {
+		t.Errorf("unexpected first record: %+v", records[0])
+	}
+}
+
+func TestReadSelectionLog_MalformedLine(t *testing.T) {
+	_, err := ReadSelectionLog(strings.NewReader("{\"query\":\"a\"}\nnot json\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Fatalf("expected line 2 error, got %v", err)
+	}
+}
+
+func TestLearnBoosts_ImprovesOnBaseline(t *testing.T) {
+	docs := makeLearnDocs()
+	records := []SelectionRecord{
+		{Query: "deploy", Shown: []string{"ops:history", "ops:deploy"}, Selected: "ops:deploy"},
+		{Query: "deploy", Shown: []string{"ops:history", "ops:deploy"}, Selected: "ops:deploy"},
+	}
+
+	result, err := LearnBoosts(docs, records, LearnOptions{})
+	if err != nil {
+		t.Fatalf("LearnBoosts error: %v", err)
+	}
+	if result.BaselineMRR != 0.5 {
+		t.Errorf("BaselineMRR = %v, want 0.5", result.BaselineMRR)
+	}
+	if result.LoggedMRR != 0.5 {
+		t.Errorf("LoggedMRR = %v, want 0.5", result.LoggedMRR)
+	}
+	if result.MRR != 1 {
+		t.Errorf("MRR = %v, want 1", result.MRR)
+	}
+	if result.Config.NameBoost <= 3 {
+		t.Errorf("expected NameBoost above default, got %d", result.Config.NameBoost)
+	}
+	if result.Evaluated != 6*6*6 {
+		t.Errorf("Evaluated = %d, want %d", result.Evaluated, 6*6*6)
+	}
+
+	// The recommendation must hold when applied.
+	s := NewBM25Searcher(result.Config)
+	results, err := s.Search("deploy", 1, docs)
+	if err != nil {
+		t.Fatalf("Search error: %v", err)
+	}
+	if len(results) != 1 || results[0].ID != "ops:deploy" {
+		t.Errorf("learned config did not rank ops:deploy first: %v", results)
+	}
+}
+
+func TestLearnBoosts_KeepsBaselineOnTie(t *testing.T) {
+	docs := makeLearnDocs()
+	records := []SelectionRecord{{Query: "history", Selected: "ops:history"}}
+	base := BM25Config{NameBoost: 2, NamespaceBoost: 2, TagsBoost: 2, MaxDocTextLen: 500}
+
+	result, err := LearnBoosts(docs, records, LearnOptions{Base: base, MaxBoost: 3})
+	if err != nil {
+		t.Fatalf("LearnBoosts error: %v", err)
+	}
+	if result.Config != base {
+		t.Errorf("Config = %+v, want baseline %+v", result.Config, base)
+	}
+}
+
+// funcPolicy is not comparable, and neither is a BM25Config holding it.
+type funcPolicy func(docs []toolindex.SearchDoc, n int) []toolindex.SearchDoc
+
+func (f funcPolicy) Select(docs []toolindex.SearchDoc, n int) []toolindex.SearchDoc {
+	return f(docs, n)
+}
+
+func TestLearnBoosts_NonComparableBase(t *testing.T) {
+	docs := makeLearnDocs()
+	records := []SelectionRecord{{Query: "deploy", Selected: "ops:deploy"}}
+	base := BM25Config{Truncation: funcPolicy(TruncateByID().Select)}
+
+	// The default boosts are among the candidates, so the baseline is
+	// recognized and skipped.
+	result, err := LearnBoosts(docs, records, LearnOptions{Base: base})
+	if err != nil {
+		t.Fatalf("LearnBoosts error: %v", err)
+	}
+	if result.Evaluated != 6*6*6 {
+		t.Errorf("Evaluated = %d, want %d", result.Evaluated, 6*6*6)
+	}
+}
+
+// countingObserver counts searches.
+type countingObserver struct {
+	NopObserver
+	searches int
+}
+
+func (o *countingObserver) SearchEnd(context.Context, SearchEndEvent) { o.searches++ }
+
+func TestLearnBoosts_ScoresWithoutLogging(t *testing.T) {
+	var buf bytes.Buffer
+	log := NewQueryLogger(&buf, QueryLogOptions{})
+	obs := &countingObserver{}
+	base := BM25Config{QueryLog: log, Observer: obs, CacheSize: 16, AsyncRebuild: true}
+	records := []SelectionRecord{{Query: "deploy", Selected: "ops:deploy"}}
+
+	result, err := LearnBoosts(makeLearnDocs(), records, LearnOptions{Base: base, MaxBoost: 2})
+	if err != nil {
+		t.Fatalf("LearnBoosts error: %v", err)
+	}
+	if err := log.Flush(); err != nil {
+		t.Fatalf("Flush error: %v", err)
+	}
+	if buf.Len() != 0 || obs.searches != 0 {
+		t.Errorf("scoring logged %q and observed %d searches", buf.String(), obs.searches)
+	}
+	if result.Config.QueryLog != log || result.Config.Observer != obs || result.Config.CacheSize != 16 || !result.Config.AsyncRebuild {
+		t.Errorf("Config dropped base settings: %+v", result.Config)
+	}
+}
+
+func TestLearnBoosts_SkipsUnusableRecords(t *testing.T) {
+	docs := makeLearnDocs()
+	records := []SelectionRecord{
+		{Query: "  ", Selected: "ops:deploy"},
+		{Query: "deploy", Selected: "missing:tool"},
+	}
+
+	_, err := LearnBoosts(docs, records, LearnOptions{MaxBoost: 1})
+	if !errors.Is(err, ErrNoSelections) {
+		t.Fatalf("expected ErrNoSelections, got %v", err)
+	}
+
+	records = append(records, SelectionRecord{Query: "deploy", Selected: "ops:deploy"})
+	result, err := LearnBoosts(docs, records, LearnOptions{MaxBoost: 1})
+	if err != nil {
+		t.Fatalf("LearnBoosts error: %v", err)
+	}
+	if result.Records != 1 || result.Skipped != 2 {
+		t.Errorf("Records=%d Skipped=%d, want 1 and 2", result.Records, result.Skipped)
+	}
+}
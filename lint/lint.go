@@ -0,0 +1,341 @@
+// Package lint checks a tool catalog for problems that make tools rank
+// poorly: missing or thin descriptions, near-duplicate documents, names
+// shared across namespaces, generic names, tags on nearly every tool, and
+// DocText that MaxDocTextLen would truncate.
+//
+//	report := lint.Lint(docs, lint.Options{MaxDocTextLen: 5000})
+//	if report.Errors > 0 {
+//		_ = report.WriteText(os.Stderr)
+//	}
+//
+// Findings carry a rule name and a severity, and the report marshals to
+// JSON, so CI jobs can gate on them.
+package lint
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"unicode"
+
+	"github.com/jonwraymond/toolindex"
+	"github.com/jonwraymond/toolsearch/internal/cluster"
+)
+
+// Severity ranks findings. Errors are tools that can hardly be found;
+// warnings are tools that rank worse than they should.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Rule names, as reported in Finding.Rule and accepted by Options.Disable.
+const (
+	RuleMissingDescription = "missing-description"
+	RuleThinDescription    = "thin-description"
+	RuleNearDuplicate      = "near-duplicate"
+	RuleDuplicateName      = "duplicate-name"
+	RuleGenericName        = "generic-name"
+	RuleCommonTag          = "common-tag"
+	RuleDocTextTooLong     = "doctext-too-long"
+)
+
+// DefaultGenericNames are the words a tool name must not consist of
+// entirely, such as "run" or "get_data".
+var DefaultGenericNames = []string{
+	"action", "api", "call", "data", "do", "exec", "execute", "fetch", "get",
+	"handle", "handler", "helper", "info", "invoke", "item", "main", "misc",
+	"process", "query", "request", "run", "search", "set", "test", "tool",
+	"tools", "util", "utils",
+}
+
+// Options configures Lint.
+type Options struct {
+	// MaxDocTextLen flags DocText longer than this many bytes, which a
+	// searcher with the same BM25Config.MaxDocTextLen truncates. Zero
+	// skips the check.
+	MaxDocTextLen int
+
+	// MinDescriptionWords is the number of distinct words DocText should
+	// have beyond the tool's name, namespace and tags (default 3).
+	MinDescriptionWords int
+
+	// DuplicateThreshold is the Jaccard similarity of two DocText word
+	// sets at or above which the tools are near duplicates (default 0.9).
+	DuplicateThreshold float64
+
+	// CommonTagRatio flags tags on more than this fraction of tools
+	// (default 0.5). Such tags have a low IDF and barely affect ranking.
+	// Catalogs of fewer than 10 tools are not checked.
+	CommonTagRatio float64
+
+	// GenericNames replaces DefaultGenericNames.
+	GenericNames []string
+
+	// Disable lists rules to skip.
+	Disable []string
+}
+
+// Finding is one problem. ID is the tool it concerns, empty for
+// catalog-wide findings; Related lists the other tools involved.
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	ID       string   `json:"id,omitempty"`
+	Related  []string `json:"related,omitempty"`
+	Message  string   `json:"message"`
+}
+
+// Report is the result of Lint.
+type Report struct {
+	Docs     int       `json:"docs"`
+	Errors   int       `json:"errors"`
+	Warnings int       `json:"warnings"`
+	Findings []Finding `json:"findings"`
+}
+
+// minCommonTagDocs is the smallest catalog checked for common tags.
+const minCommonTagDocs = 10
+
+// Lint checks docs. Findings are ordered by tool ID, catalog-wide ones
+// first, then by rule.
+func Lint(docs []toolindex.SearchDoc, opts Options) Report {
+	if opts.MinDescriptionWords <= 0 {
+		opts.MinDescriptionWords = 3
+	}
+	if opts.DuplicateThreshold <= 0 {
+		opts.DuplicateThreshold = 0.9
+	}
+	if opts.CommonTagRatio <= 0 {
+		opts.CommonTagRatio = 0.5
+	}
+	if opts.GenericNames == nil {
+		opts.GenericNames = DefaultGenericNames
+	}
+
+	docs = slices.Clone(docs)
+	slices.SortFunc(docs, func(a, b toolindex.SearchDoc) int { return strings.Compare(a.ID, b.ID) })
+
+	l := &linter{opts: opts, report: Report{Docs: len(docs), Findings: []Finding{}}}
+	generic := make(map[string]bool, len(opts.GenericNames))
+	for _, name := range opts.GenericNames {
+		generic[strings.ToLower(name)] = true
+	}
+	for _, doc := range docs {
+		l.description(doc)
+		l.genericName(doc, generic)
+		l.docTextLength(doc)
+	}
+	l.duplicateNames(docs)
+	l.commonTags(docs)
+	l.nearDuplicates(docs)
+
+	slices.SortStableFunc(l.report.Findings, func(a, b Finding) int {
+		return cmp.Or(strings.Compare(a.ID, b.ID), strings.Compare(a.Rule, b.Rule))
+	})
+	return l.report
+}
+
+type linter struct {
+	opts   Options
+	report Report
+}
+
+func (l *linter) add(f Finding) {
+	if slices.Contains(l.opts.Disable, f.Rule) {
+		return
+	}
+	switch f.Severity {
+	case SeverityError:
+		l.report.Errors++
+	case SeverityWarning:
+		l.report.Warnings++
+	}
+	l.report.Findings = append(l.report.Findings, f)
+}
+
+// description flags tools whose DocText says little beyond the name,
+// namespace and tags, and summaries without a short description.
+func (l *linter) description(doc toolindex.SearchDoc) {
+	known := make(map[string]bool)
+	for _, s := range append([]string{doc.Summary.Name, doc.Summary.Namespace}, doc.Summary.Tags...) {
+		for _, w := range words(s) {
+			known[w] = true
+		}
+	}
+	extra := make(map[string]bool)
+	for _, w := range words(doc.DocText) {
+		if !known[w] {
+			extra[w] = true
+		}
+	}
+
+	switch {
+	case len(extra) == 0:
+		l.add(Finding{Rule: RuleMissingDescription, Severity: SeverityError, ID: doc.ID,
+			Message: "DocText has no words beyond the name, namespace and tags"})
+	case len(extra) < l.opts.MinDescriptionWords:
+		l.add(Finding{Rule: RuleThinDescription, Severity: SeverityWarning, ID: doc.ID,
+			Message: fmt.Sprintf("DocText has %d words beyond the name, namespace and tags; want at least %d",
+				len(extra), l.opts.MinDescriptionWords)})
+	case strings.TrimSpace(doc.Summary.ShortDescription) == "":
+		l.add(Finding{Rule: RuleThinDescription, Severity: SeverityWarning, ID: doc.ID,
+			Message: "summary has no short description"})
+	}
+}
+
+// genericName flags names made only of generic words.
+func (l *linter) genericName(doc toolindex.SearchDoc, generic map[string]bool) {
+	ws := words(doc.Summary.Name)
+	if len(ws) == 0 {
+		return
+	}
+	for _, w := range ws {
+		if !generic[w] {
+			return
+		}
+	}
+	l.add(Finding{Rule: RuleGenericName, Severity: SeverityWarning, ID: doc.ID,
+		Message: fmt.Sprintf("name %q does not say what the tool acts on", doc.Summary.Name)})
+}
+
+func (l *linter) docTextLength(doc toolindex.SearchDoc) {
+	if l.opts.MaxDocTextLen <= 0 || len(doc.DocText) <= l.opts.MaxDocTextLen {
+		return
+	}
+	l.add(Finding{Rule: RuleDocTextTooLong, Severity: SeverityWarning, ID: doc.ID,
+		Message: fmt.Sprintf("DocText is %d bytes; MaxDocTextLen %d drops the rest", len(doc.DocText), l.opts.MaxDocTextLen)})
+}
+
+// duplicateNames flags names used in more than one namespace. Docs are
+// sorted by ID.
+func (l *linter) duplicateNames(docs []toolindex.SearchDoc) {
+	byName := make(map[string][]toolindex.SearchDoc)
+	var names []string
+	for _, doc := range docs {
+		name := strings.ToLower(doc.Summary.Name)
+		if name == "" {
+			continue
+		}
+		if byName[name] == nil {
+			names = append(names, name)
+		}
+		byName[name] = append(byName[name], doc)
+	}
+	for _, name := range names {
+		group := byName[name]
+		namespaces := make(map[string]bool)
+		for _, doc := range group {
+			namespaces[doc.Summary.Namespace] = true
+		}
+		if len(namespaces) < 2 {
+			continue
+		}
+		l.add(Finding{Rule: RuleDuplicateName, Severity: SeverityWarning, ID: group[0].ID, Related: ids(group[1:]),
+			Message: fmt.Sprintf("name %q is used in %d namespaces; only the namespace and description tell them apart",
+				group[0].Summary.Name, len(namespaces))})
+	}
+}
+
+// commonTags flags tags on more than CommonTagRatio of docs.
+func (l *linter) commonTags(docs []toolindex.SearchDoc) {
+	if len(docs) < minCommonTagDocs {
+		return
+	}
+	counts := make(map[string]int)
+	for _, doc := range docs {
+		seen := make(map[string]bool)
+		for _, tag := range doc.Summary.Tags {
+			tag = strings.ToLower(tag)
+			if !seen[tag] {
+				seen[tag] = true
+				counts[tag]++
+			}
+		}
+	}
+	tags := make([]string, 0, len(counts))
+	for tag := range counts {
+		tags = append(tags, tag)
+	}
+	slices.Sort(tags)
+	for _, tag := range tags {
+		if float64(counts[tag]) <= l.opts.CommonTagRatio*float64(len(docs)) {
+			continue
+		}
+		l.add(Finding{Rule: RuleCommonTag, Severity: SeverityWarning,
+			Message: fmt.Sprintf("tag %q is on %d of %d tools and barely affects ranking", tag, counts[tag], len(docs))})
+	}
+}
+
+// nearDuplicates flags groups of docs whose DocText word sets have a
+// Jaccard similarity of at least DuplicateThreshold, pairwise linked.
+// Candidate pairs come from a prefix filter: with words ordered rarest
+// first, two sets that similar must share a word among the first
+// |x| - ceil(t|x|) + 1 of each, so only those are indexed.
+func (l *linter) nearDuplicates(docs []toolindex.SearchDoc) {
+	if slices.Contains(l.opts.Disable, RuleNearDuplicate) {
+		return
+	}
+	groups := cluster.Similar(len(docs), func(i int) []string {
+		return distinct(words(docs[i].DocText))
+	}, l.opts.DuplicateThreshold)
+	for _, members := range groups {
+		group := make([]toolindex.SearchDoc, len(members))
+		for k, i := range members {
+			group[k] = docs[i]
+		}
+		l.add(Finding{Rule: RuleNearDuplicate, Severity: SeverityWarning, ID: group[0].ID, Related: ids(group[1:]),
+			Message: fmt.Sprintf("%d tools have near-identical DocText; searches cannot tell them apart", len(group))})
+	}
+}
+
+// words splits s into lowercase runs of letters and digits, so
+// "create_issue" yields "create" and "issue".
+func words(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func distinct(ws []string) []string {
+	slices.Sort(ws)
+	return slices.Compact(ws)
+}
+
+func ids(docs []toolindex.SearchDoc) []string {
+	out := make([]string, len(docs))
+	for i, doc := range docs {
+		out[i] = doc.ID
+	}
+	return out
+}
+
+// WriteText renders the report as an aligned plain-text table followed by
+// a summary line.
+func (r Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if len(r.Findings) > 0 {
+		fmt.Fprintln(tw, "SEVERITY\tRULE\tID\tMESSAGE")
+	}
+	for _, f := range r.Findings {
+		id := f.ID
+		if id == "" {
+			id = "-"
+		}
+		msg := f.Message
+		if len(f.Related) > 0 {
+			msg += " (also " + strings.Join(f.Related, ", ") + ")"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", f.Severity, f.Rule, id, msg)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%d tools: %d errors, %d warnings\n", r.Docs, r.Errors, r.Warnings)
+	return err
+}
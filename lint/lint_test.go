@@ -0,0 +1,178 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jonwraymond/toolindex"
+	"github.com/jonwraymond/toolmodel"
+	"github.com/jonwraymond/toolsearch"
+	"github.com/jonwraymond/toolsearch/internal/cluster"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func tool(id, description string, tags ...string) toolindex.SearchDoc {
+	namespace, name, _ := strings.Cut(id, ":")
+	return toolsearch.SearchDocFromTool(toolmodel.Tool{
+		Tool:      mcp.Tool{Name: name, Description: description, InputSchema: map[string]any{"type": "object"}},
+		Namespace: namespace,
+		Tags:      append(tags, "mcp"),
+	})
+}
+
+const mailDescription = "Send an email message to one or more recipients with optional attachments, carbon copies, reply-to headers and delivery receipts over SMTP"
+
+func catalog() []toolindex.SearchDoc {
+	return []toolindex.SearchDoc{
+		tool("github:create_issue", "Create a new issue in a GitHub repository", "issues"),
+		tool("gitlab:create_issue", "Open a ticket in a GitLab project backlog", "issues"),
+		tool("ops:run", ""),
+		tool("ops:get_data", "Gets data", "data"),
+		tool("mail:send_email", mailDescription),
+		tool("mail:send_email_v2", mailDescription),
+		tool("k8s:scale_deployment", "Scale a Kubernetes deployment to a number of replicas"),
+		tool("k8s:rollout_status", "Report the rollout progress of a Kubernetes deployment"),
+		tool("dns:update_record", "Update an A or CNAME record in a hosted DNS zone"),
+		tool("docs:render", "Render markdown documentation into HTML pages "+strings.Repeat("and more ", 40)),
+	}
+}
+
+func TestLint(t *testing.T) {
+	report := Lint(catalog(), Options{MaxDocTextLen: 200})
+
+	var got []string
+	for _, f := range report.Findings {
+		got = append(got, fmt.Sprintf("%s %s %s %v", f.Severity, f.Rule, f.ID, f.Related))
+	}
+	want := []string{
+		"warning common-tag  []",
+		"warning doctext-too-long docs:render []",
+		"warning duplicate-name github:create_issue [gitlab:create_issue]",
+		"warning near-duplicate mail:send_email [mail:send_email_v2]",
+		"warning generic-name ops:get_data []",
+		"warning thin-description ops:get_data []",
+		"warning generic-name ops:run []",
+		"error missing-description ops:run []",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findings:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if report.Docs != 10 || report.Errors != 1 || report.Warnings != 7 {
+		t.Errorf("report = %d docs, %d errors, %d warnings", report.Docs, report.Errors, report.Warnings)
+	}
+	if msg := report.Findings[0].Message; !strings.Contains(msg, `"mcp" is on 10 of 10 tools`) {
+		t.Errorf("common-tag message = %q", msg)
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(data, &decoded); err != nil || !reflect.DeepEqual(decoded, report) {
+		t.Errorf("JSON round trip = %+v, %v", decoded, err)
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteText(&buf); err != nil {
+		t.Fatalf("WriteText error: %v", err)
+	}
+	if out := buf.String(); !strings.Contains(out, "(also mail:send_email_v2)") || !strings.HasSuffix(out, "10 tools: 1 errors, 7 warnings\n") {
+		t.Errorf("text report:\n%s", out)
+	}
+}
+
+func TestLint_Options(t *testing.T) {
+	report := Lint(catalog(), Options{
+		Disable:        []string{RuleCommonTag, RuleNearDuplicate, RuleDuplicateName, RuleThinDescription},
+		GenericNames:   []string{"render"},
+		CommonTagRatio: 2,
+	})
+	var got []string
+	for _, f := range report.Findings {
+		got = append(got, f.Rule+" "+f.ID)
+	}
+	want := []string{"generic-name docs:render", "missing-description ops:run"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findings = %v, want %v", got, want)
+	}
+
+	if report := Lint(catalog()[:5], Options{}); len(report.Findings) == 0 || report.Findings[0].Rule == RuleCommonTag {
+		t.Errorf("small catalog checked for common tags: %+v", report.Findings)
+	}
+}
+
+// TestNearDuplicates_MatchesBruteForce checks the prefix filter against
+// comparing every pair.
+func TestNearDuplicates_MatchesBruteForce(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	var docs []toolindex.SearchDoc
+	for i := range 300 {
+		var ws []string
+		n := 3 + r.IntN(20)
+		base := r.IntN(40)
+		for j := range n {
+			w := base + j
+			if r.IntN(10) == 0 {
+				w = r.IntN(200)
+			}
+			ws = append(ws, fmt.Sprintf("w%d", w))
+		}
+		id := fmt.Sprintf("t%03d", i)
+		docs = append(docs, toolindex.SearchDoc{ID: id, DocText: strings.Join(ws, " "), Summary: toolindex.Summary{ID: id}})
+	}
+
+	for _, threshold := range []float64{0.5, 0.8, 0.9} {
+		report := Lint(docs, Options{DuplicateThreshold: threshold, Disable: []string{RuleMissingDescription, RuleThinDescription}})
+		var got []string
+		for _, f := range report.Findings {
+			got = append(got, f.ID+" "+strings.Join(f.Related, " "))
+		}
+
+		parent := make([]int, len(docs))
+		for i := range parent {
+			parent[i] = i
+		}
+		var find func(int) int
+		find = func(i int) int {
+			if parent[i] != i {
+				parent[i] = find(parent[i])
+			}
+			return parent[i]
+		}
+		for i := range docs {
+			for j := range i {
+				a, b := distinct(words(docs[i].DocText)), distinct(words(docs[j].DocText))
+				if cluster.Jaccard(a, b) >= threshold {
+					parent[find(i)] = find(j)
+				}
+			}
+		}
+		groups := make(map[int][]string)
+		var roots []int
+		for i, doc := range docs {
+			root := find(i)
+			if groups[root] == nil {
+				roots = append(roots, root)
+			}
+			groups[root] = append(groups[root], doc.ID)
+		}
+		var want []string
+		for _, root := range roots {
+			if g := groups[root]; len(g) > 1 {
+				want = append(want, strings.Join(g, " "))
+			}
+		}
+		if len(want) == 0 {
+			t.Fatalf("threshold %v: corpus has no near duplicates", threshold)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("threshold %v: groups\n%v\nwant\n%v", threshold, got, want)
+		}
+	}
+}
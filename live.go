@@ -0,0 +1,360 @@
+package toolsearch
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jonwraymond/toolindex"
+)
+
+// CatalogSource is a tool catalog that reports changes, which a
+// LiveSearcher follows to update its index incrementally.
+type CatalogSource interface {
+	// Lookup returns the current search document for a tool ID. ok is
+	// false when the tool no longer exists.
+	Lookup(id string) (doc toolindex.SearchDoc, ok bool, err error)
+
+	// OnChange registers a listener for catalog changes and returns a
+	// function that unregisters it. Events carry the changed ToolID, or
+	// none when the whole catalog may have changed.
+	OnChange(listener toolindex.ChangeListener) (unsubscribe func())
+}
+
+// NotifyingIndex is a toolindex.Index that emits change events, such as
+// *toolindex.InMemoryIndex.
+type NotifyingIndex interface {
+	toolindex.Index
+	toolindex.ChangeNotifier
+}
+
+// IndexSource adapts a toolindex index to a CatalogSource. Documents are
+// derived with SearchDocFromTool, matching the docs the index builds.
+func IndexSource(idx NotifyingIndex) CatalogSource {
+	return indexSource{idx}
+}
+
+type indexSource struct {
+	NotifyingIndex
+}
+
+func (s indexSource) Lookup(id string) (toolindex.SearchDoc, bool, error) {
+	tool, _, err := s.GetTool(id)
+	if errors.Is(err, toolindex.ErrNotFound) {
+		return toolindex.SearchDoc{}, false, nil
+	}
+	if err != nil {
+		return toolindex.SearchDoc{}, false, err
+	}
+	return SearchDocFromTool(tool), true, nil
+}
+
+// LiveSearcher is a BM25 searcher that keeps its index in sync with a
+// CatalogSource. After the first build, registrations and removals are
+// applied to the Bleve index one document at a time, and searches ignore
+// the docs argument, so a warm search costs O(query) instead of
+// O(catalog).
+//
+// It still implements toolindex.Searcher. Until it follows a source, or
+// while it cannot apply a change, it behaves exactly like BM25Searcher and
+// searches the docs it is given. Incremental updates are disabled when
+// MaxDocs is set, because the capped subset depends on the whole catalog.
+//
+// Typical wiring with toolindex:
+//
+//	live := toolsearch.NewLiveSearcher(toolsearch.BM25Config{})
+//	idx := toolindex.NewInMemoryIndex(toolindex.IndexOptions{Searcher: live})
+//	stop := live.Follow(toolsearch.IndexSource(idx))
+//	defer stop()
+type LiveSearcher struct {
+	bm *BM25Searcher
+
+	mu      sync.Mutex // serializes change handling
+	src     CatalogSource
+	stop    func()
+	pending map[string]struct{} // tool IDs not yet applied to the index
+	gen     uint64
+	updates int
+}
+
+// Ensure interface compliance at compile time.
+var _ toolindex.Searcher = (*LiveSearcher)(nil)
+var _ toolindex.DeterministicSearcher = (*LiveSearcher)(nil)
+
+// NewLiveSearcher creates a live searcher with the given config. Zero
+// values in config are replaced with the BM25Searcher defaults.
+func NewLiveSearcher(cfg BM25Config) *LiveSearcher {
+	return &LiveSearcher{
+		bm:      NewBM25Searcher(cfg),
+		pending: make(map[string]struct{}),
+	}
+}
+
+// Follow subscribes to src, replacing any previously followed source. The
+// returned function unsubscribes. Changes that arrive before the index is
+// first built are applied with the next change after it.
+func (s *LiveSearcher) Follow(src CatalogSource) (stop func()) {
+	s.mu.Lock()
+	if s.stop != nil {
+		s.stop()
+	}
+	s.src = src
+	clear(s.pending)
+	s.mu.Unlock()
+
+	unsubscribe := src.OnChange(s.handleChange)
+
+	s.mu.Lock()
+	s.stop = unsubscribe
+	s.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			unsubscribe()
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if s.src == src {
+				s.src, s.stop = nil, nil
+				s.bm.invalidateLive()
+			}
+		})
+	}
+}
+
+// Deterministic reports whether this searcher returns stable ordering.
+func (s *LiveSearcher) Deterministic() bool {
+	return true
+}
+
+// Search performs a BM25-ranked search. While the index is live, docs is
+// ignored.
+func (s *LiveSearcher) Search(query string, limit int, docs []toolindex.SearchDoc) ([]toolindex.Summary, error) {
+	result, err := s.SearchWithOptions(query, limit, docs, SearchOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return result.Summaries(), nil
+}
+
+// SearchWithOptions performs a scored search like
+// BM25Searcher.SearchWithOptions. While the index is live, docs and
+// opts.CatalogVersion are ignored.
+func (s *LiveSearcher) SearchWithOptions(query string, limit int, docs []toolindex.SearchDoc, opts SearchOptions) (SearchResult, error) {
+	return s.bm.search(query, limit, docs, opts, true)
+}
+
+// IndexBuildCount returns the number of full index builds.
+func (s *LiveSearcher) IndexBuildCount() int {
+	return s.bm.IndexBuildCount()
+}
+
+// UpdateCount returns the number of incremental updates applied.
+func (s *LiveSearcher) UpdateCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.updates
+}
+
+// CacheStats returns query result cache counters.
+func (s *LiveSearcher) CacheStats() CacheStats {
+	return s.bm.CacheStats()
+}
+
+// Stats returns the size of the current index.
+func (s *LiveSearcher) Stats() IndexStats {
+	return s.bm.Stats()
+}
+
+// Truncations reports the docs dropped from the current index's catalog.
+func (s *LiveSearcher) Truncations() []TruncationReport {
+	return s.bm.Truncations()
+}
+
+// Snapshot writes the current index to w; see BM25Searcher.Snapshot.
+func (s *LiveSearcher) Snapshot(w io.Writer) error {
+	return s.bm.Snapshot(w)
+}
+
+// Restore replaces the index with a snapshot; see BM25Searcher.Restore.
+// Changes from a followed source are applied on top of it.
+func (s *LiveSearcher) Restore(r io.Reader) error {
+	return s.bm.Restore(r)
+}
+
+// Close unsubscribes from the source and releases the index.
+func (s *LiveSearcher) Close() error {
+	s.mu.Lock()
+	if s.stop != nil {
+		s.stop()
+	}
+	s.src, s.stop = nil, nil
+	s.mu.Unlock()
+	return s.bm.Close()
+}
+
+func (s *LiveSearcher) handleChange(ev toolindex.ChangeEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.src == nil {
+		return
+	}
+	if ev.ToolID == "" {
+		// Refreshes and bulk changes do not name the tools they touch.
+		// Resync the whole catalog from the next search's docs.
+		clear(s.pending)
+		s.bm.invalidateLive()
+		return
+	}
+	s.pending[ev.ToolID] = struct{}{}
+	if s.bm.cfg.MaxDocs > 0 {
+		// Searches compare docs instead; nothing to apply.
+		clear(s.pending)
+		return
+	}
+	if !s.bm.hasIndex() {
+		return
+	}
+
+	ids := make([]string, 0, len(s.pending))
+	for id := range s.pending {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	updates := make([]docUpdate, 0, len(ids))
+	for _, id := range ids {
+		doc, ok, err := s.src.Lookup(id)
+		if err != nil {
+			// Fall back to a full build from the next search's docs.
+			s.bm.invalidateLive()
+			return
+		}
+		updates = append(updates, docUpdate{id: id, doc: doc, ok: ok})
+	}
+
+	s.gen++
+	if err := s.bm.applyUpdates(updates, "live:"+strconv.FormatUint(s.gen, 10)); err != nil {
+		s.bm.invalidateLive()
+		return
+	}
+	s.updates += len(updates)
+	clear(s.pending)
+}
+
+// docUpdate is the new state of one document; ok is false for removals.
+type docUpdate struct {
+	id  string
+	doc toolindex.SearchDoc
+	ok  bool
+}
+
+// errNotReady reports that the index cannot take incremental updates.
+var errNotReady = errors.New("index not ready for updates")
+
+// hasIndex reports whether the index has been built from a full catalog.
+func (s *BM25Searcher) hasIndex() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.index != nil && s.lastSorted != nil
+}
+
+// applyUpdates writes updates to the current index and marks it live
+// under fingerprint.
+func (s *BM25Searcher) applyUpdates(updates []docUpdate, fingerprint string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.index == nil || s.lastSorted == nil || len(s.truncations) > 0 {
+		// A truncated catalog must be re-selected as a whole.
+		return errNotReady
+	}
+
+	// The size of the catalog is measured once per full build and then
+	// adjusted by each update, so an update costs O(update), not
+	// O(catalog). s.size is only kept if the update is applied.
+	size := s.size
+	s.size = nil
+	if s.cfg.MaxIndexBytes > 0 && size == nil {
+		size = newIndexSizer()
+		for _, doc := range s.lastSorted {
+			size.add(doc, buildWeightedDoc(s.cfg, doc))
+		}
+	}
+
+	// lastSorted may be in use by searches outside the lock; copy on write.
+	sorted := slices.Clone(s.lastSorted)
+	for _, u := range updates {
+		i, found := slices.BinarySearchFunc(sorted, u.id, func(d toolindex.SearchDoc, id string) int {
+			return strings.Compare(d.ID, id)
+		})
+		if size != nil {
+			if found {
+				size.remove(sorted[i], buildWeightedDoc(s.cfg, sorted[i]))
+			}
+			if u.ok {
+				size.add(u.doc, buildWeightedDoc(s.cfg, u.doc))
+			}
+		}
+		switch {
+		case !u.ok && found:
+			sorted = slices.Delete(sorted, i, i+1)
+		case u.ok && found:
+			sorted[i] = u.doc
+		case u.ok:
+			sorted = slices.Insert(sorted, i, u.doc)
+		}
+	}
+	// An update past the budget falls back to a full build, which rejects
+	// or truncates the catalog.
+	if size != nil && size.bytes > s.cfg.MaxIndexBytes {
+		return &IndexBudgetError{Limit: s.cfg.MaxIndexBytes, Estimated: size.bytes, Docs: len(sorted)}
+	}
+
+	batch := newIndexBatch(s.index)
+	for _, u := range updates {
+		if !u.ok {
+			batch.Delete(u.id)
+			continue
+		}
+		if err := batch.Index(u.id, newIndexedDoc(buildWeightedDoc(s.cfg, u.doc), u.doc)); err != nil {
+			return err
+		}
+	}
+	if err := batch.apply(); err != nil {
+		return err
+	}
+	for _, u := range updates {
+		if u.ok {
+			s.idToSummary[u.id] = u.doc.Summary
+		} else {
+			delete(s.idToSummary, u.id)
+		}
+	}
+
+	s.lastSorted = sorted
+	s.lastFingerprint = fingerprint
+	s.lastInput, s.lastVersion = nil, ""
+	s.live = true
+	s.stats, s.dups, s.size = nil, nil, size
+	if s.cache != nil {
+		s.cache.purge()
+	}
+	return nil
+}
+
+// invalidateLive forces the next search to rebuild from its docs.
+func (s *BM25Searcher) invalidateLive() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.live = false
+	s.lastFingerprint = ""
+	s.lastInput, s.lastSorted, s.lastVersion = nil, nil, ""
+	s.size = nil
+	if s.cache != nil {
+		s.cache.purge()
+	}
+}
@@ -0,0 +1,261 @@
+package toolsearch
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/jonwraymond/toolindex"
+	"github.com/jonwraymond/toolmodel"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+var liveBackend = toolmodel.ToolBackend{Kind: toolmodel.BackendKindMCP, MCP: &toolmodel.MCPBackend{ServerName: "srv"}}
+
+func liveTool(name, ns, desc string) toolmodel.Tool {
+	return toolmodel.Tool{
+		Tool: mcp.Tool{
+			Name:        name,
+			Description: desc,
+			InputSchema: map[string]any{"type": "object"},
+		},
+		Namespace: ns,
+	}
+}
+
+func newLiveIndex(t *testing.T, cfg BM25Config) (*LiveSearcher, *toolindex.InMemoryIndex) {
+	t.Helper()
+	live := NewLiveSearcher(cfg)
+	idx := toolindex.NewInMemoryIndex(toolindex.IndexOptions{Searcher: live})
+	t.Cleanup(func() { _ = live.Close() })
+	return live, idx
+}
+
+func register(t *testing.T, idx *toolindex.InMemoryIndex, tools ...toolmodel.Tool) {
+	t.Helper()
+	for _, tool := range tools {
+		if err := idx.RegisterTool(tool, liveBackend); err != nil {
+			t.Fatalf("RegisterTool(%s) error: %v", tool.Name, err)
+		}
+	}
+}
+
+func searchIDs(t *testing.T, idx *toolindex.InMemoryIndex, query string) []string {
+	t.Helper()
+	results, err := idx.Search(query, 10)
+	if err != nil {
+		t.Fatalf("Search(%q) error: %v", query, err)
+	}
+	ids := make([]string, len(results))
+	for i, r := range results {
+		ids[i] = r.ID
+	}
+	return ids
+}
+
+func TestLiveSearcher_AppliesChangesIncrementally(t *testing.T) {
+	live, idx := newLiveIndex(t, BM25Config{})
+	register(t, idx,
+		liveTool("status", "git", "Show the working tree status"),
+		liveTool("commit", "git", "Record changes to the repository"),
+	)
+	live.Follow(IndexSource(idx))
+
+	if got := searchIDs(t, idx, "repository"); !slices.Equal(got, []string{"git:commit"}) {
+		t.Fatalf("initial search = %v", got)
+	}
+
+	register(t, idx, liveTool("clone", "git", "Clone a repository into a new directory"))
+	if got := searchIDs(t, idx, "repository"); len(got) != 2 || !slices.Contains(got, "git:clone") {
+		t.Errorf("search after register = %v, want git:clone included", got)
+	}
+
+	tagged := liveTool("commit", "git", "Record changes to the repository")
+	tagged.Tags = []string{"history"}
+	register(t, idx, tagged)
+	if got := searchIDs(t, idx, "history"); !slices.Equal(got, []string{"git:commit"}) {
+		t.Errorf("search after update = %v, want [git:commit]", got)
+	}
+
+	if err := idx.UnregisterBackend("git:clone", toolmodel.BackendKindMCP, "srv"); err != nil {
+		t.Fatalf("UnregisterBackend error: %v", err)
+	}
+	if got := searchIDs(t, idx, "clone"); len(got) != 0 {
+		t.Errorf("search after removal = %v, want none", got)
+	}
+	if got := searchIDs(t, idx, ""); !slices.Equal(got, []string{"git:commit", "git:status"}) {
+		t.Errorf("empty query = %v, want sorted remaining tools", got)
+	}
+
+	if live.IndexBuildCount() != 1 {
+		t.Errorf("IndexBuildCount = %d, want 1", live.IndexBuildCount())
+	}
+	if live.UpdateCount() != 3 {
+		t.Errorf("UpdateCount = %d, want 3", live.UpdateCount())
+	}
+}
+
+func TestLiveSearcher_MatchesFullRebuild(t *testing.T) {
+	live, idx := newLiveIndex(t, BM25Config{})
+	register(t, idx,
+		liveTool("ps", "docker", "List running containers"),
+		liveTool("get", "kubectl", "Display one or many resources"),
+	)
+	live.Follow(IndexSource(idx))
+	searchIDs(t, idx, "containers")
+
+	register(t, idx,
+		liveTool("run", "docker", "Run a command in a new container"),
+		liveTool("logs", "docker", "Fetch the logs of a container"),
+		liveTool("logs", "kubectl", "Print the logs for a container in a pod"),
+	)
+
+	capture := &docCapture{}
+	ref := toolindex.NewInMemoryIndex(toolindex.IndexOptions{Searcher: capture})
+	for _, tool := range []toolmodel.Tool{
+		liveTool("ps", "docker", "List running containers"),
+		liveTool("get", "kubectl", "Display one or many resources"),
+		liveTool("run", "docker", "Run a command in a new container"),
+		liveTool("logs", "docker", "Fetch the logs of a container"),
+		liveTool("logs", "kubectl", "Print the logs for a container in a pod"),
+	} {
+		if err := ref.RegisterTool(tool, liveBackend); err != nil {
+			t.Fatalf("RegisterTool error: %v", err)
+		}
+	}
+	if _, err := ref.Search("", 1); err != nil {
+		t.Fatalf("Search error: %v", err)
+	}
+	full := NewBM25Searcher(BM25Config{})
+	defer func() { _ = full.Close() }()
+
+	for _, q := range []string{"container", "logs", "docker logs", "pod"} {
+		want, err := full.Search(q, 10, capture.docs)
+		if err != nil {
+			t.Fatalf("Search error: %v", err)
+		}
+		wantIDs := make([]string, len(want))
+		for i, r := range want {
+			wantIDs[i] = r.ID
+		}
+		if got := searchIDs(t, idx, q); !slices.Equal(got, wantIDs) {
+			t.Errorf("query %q: live = %v, full rebuild = %v", q, got, wantIDs)
+		}
+	}
+	if live.IndexBuildCount() != 1 {
+		t.Errorf("IndexBuildCount = %d, want 1", live.IndexBuildCount())
+	}
+}
+
+func TestLiveSearcher_ChangesBeforeFirstBuild(t *testing.T) {
+	live, idx := newLiveIndex(t, BM25Config{})
+	live.Follow(IndexSource(idx))
+
+	// No index exists yet; these changes are picked up from the docs of
+	// the first search.
+	register(t, idx, liveTool("status", "git", "Show the working tree status"))
+	if got := searchIDs(t, idx, "status"); !slices.Equal(got, []string{"git:status"}) {
+		t.Fatalf("first search = %v", got)
+	}
+
+	register(t, idx, liveTool("diff", "git", "Show changes between commits"))
+	if got := searchIDs(t, idx, "show"); len(got) != 2 {
+		t.Errorf("search after register = %v, want both tools", got)
+	}
+}
+
+func TestLiveSearcher_StopFallsBackToDocs(t *testing.T) {
+	live, idx := newLiveIndex(t, BM25Config{})
+	register(t, idx, liveTool("status", "git", "Show the working tree status"))
+	stop := live.Follow(IndexSource(idx))
+	searchIDs(t, idx, "status")
+	register(t, idx, liveTool("diff", "git", "Show changes between commits"))
+	stop()
+
+	// Without a source, searches use the docs toolindex passes in.
+	register(t, idx, liveTool("log", "git", "Show commit logs"))
+	if got := searchIDs(t, idx, "show"); len(got) != 3 {
+		t.Errorf("search after stop = %v, want all three tools", got)
+	}
+}
+
+func TestLiveSearcher_RefreshResyncs(t *testing.T) {
+	live, idx := newLiveIndex(t, BM25Config{})
+	register(t, idx, liveTool("status", "git", "Show the working tree status"))
+	live.Follow(IndexSource(idx))
+	searchIDs(t, idx, "status")
+
+	// A refresh names no tool, so the next search rebuilds from its docs.
+	idx.Refresh()
+	if got := searchIDs(t, idx, "status"); !slices.Equal(got, []string{"git:status"}) {
+		t.Errorf("search after refresh = %v", got)
+	}
+	if live.IndexBuildCount() != 2 {
+		t.Errorf("IndexBuildCount = %d, want 2", live.IndexBuildCount())
+	}
+
+	// Changes after the resync are applied incrementally again.
+	register(t, idx, liveTool("diff", "git", "Show changes between commits"))
+	if got := searchIDs(t, idx, "show"); len(got) != 2 {
+		t.Errorf("search after register = %v, want both tools", got)
+	}
+	if live.IndexBuildCount() != 2 || live.UpdateCount() != 1 {
+		t.Errorf("IndexBuildCount = %d, UpdateCount = %d; want 2, 1", live.IndexBuildCount(), live.UpdateCount())
+	}
+}
+
+func TestLiveSearcher_MaxDocsUsesDocs(t *testing.T) {
+	live, idx := newLiveIndex(t, BM25Config{MaxDocs: 2})
+	register(t, idx,
+		liveTool("b", "ns", "shared text"),
+		liveTool("c", "ns", "shared text"),
+	)
+	live.Follow(IndexSource(idx))
+	searchIDs(t, idx, "shared")
+
+	register(t, idx, liveTool("a", "ns", "shared text"))
+	if got := searchIDs(t, idx, "shared"); !slices.Equal(got, []string{"ns:a", "ns:b"}) {
+		t.Errorf("search = %v, want the first two IDs", got)
+	}
+	if live.UpdateCount() != 0 {
+		t.Errorf("UpdateCount = %d, want 0 with MaxDocs", live.UpdateCount())
+	}
+}
+
+func TestLiveSearcher_ConcurrentChangesAndSearches(t *testing.T) {
+	live, idx := newLiveIndex(t, BM25Config{CacheSize: 16})
+	register(t, idx, liveTool("seed", "ns", "shared text"))
+	live.Follow(IndexSource(idx))
+	searchIDs(t, idx, "shared")
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := range 50 {
+			if err := idx.RegisterTool(liveTool(fmt.Sprintf("tool%d", i), "ns", "shared text"), liveBackend); err != nil {
+				t.Errorf("RegisterTool error: %v", err)
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range 50 {
+			if _, err := idx.Search("shared", 5); err != nil {
+				t.Errorf("Search error: %v", err)
+				return
+			}
+		}
+	}()
+	wg.Wait()
+
+	results, err := idx.Search("", 100)
+	if err != nil {
+		t.Fatalf("Search error: %v", err)
+	}
+	if len(results) != 51 {
+		t.Errorf("got %d tools after concurrent registration, want 51", len(results))
+	}
+}
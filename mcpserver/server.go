@@ -0,0 +1,203 @@
+// Package mcpserver exposes a toolindex.Index as an MCP server for
+// progressive tool discovery.
+//
+// The server offers three tools:
+//
+//   - search_tools ranks tool summaries for a query, with cursor paging
+//   - describe_tool returns the full definition and backends of one tool
+//   - list_namespaces lists the namespaces in the catalog
+//
+// Results are returned as structured content. Pair the index with a
+// toolsearch.BM25Searcher for ranked search:
+//
+//	idx := toolindex.NewInMemoryIndex(toolindex.IndexOptions{
+//	    Searcher: toolsearch.NewBM25Searcher(toolsearch.BM25Config{}),
+//	})
+//	srv := mcpserver.New(idx, mcpserver.Options{})
+//	err := srv.Run(ctx, &mcp.StdioTransport{})
+package mcpserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jonwraymond/toolindex"
+	"github.com/jonwraymond/toolmodel"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Options configures the MCP server.
+type Options struct {
+	Name    string // implementation name (default "toolsearch")
+	Version string // implementation version (default "dev")
+
+	DefaultLimit int // results per call when the client sends no limit (default 10)
+	MaxLimit     int // upper bound on a client-requested limit (default 50)
+}
+
+// SearchToolsInput is the input of the search_tools tool.
+type SearchToolsInput struct {
+	Query  string `json:"query" jsonschema:"free-text search over tool names, namespaces, descriptions and tags"`
+	Limit  int    `json:"limit,omitempty" jsonschema:"maximum number of results to return"`
+	Cursor string `json:"cursor,omitempty" jsonschema:"cursor from a previous call to fetch the next page"`
+}
+
+// SearchToolsOutput is the structured result of search_tools.
+type SearchToolsOutput struct {
+	Results    []toolindex.Summary `json:"results"`
+	NextCursor string              `json:"nextCursor,omitempty"`
+}
+
+// DescribeToolInput is the input of the describe_tool tool.
+type DescribeToolInput struct {
+	ID string `json:"id" jsonschema:"canonical tool ID as returned by search_tools"`
+}
+
+// Backend identifies one backend that can execute a tool.
+type Backend struct {
+	Kind string `json:"kind"`
+	ID   string `json:"id"`
+}
+
+// DescribeToolOutput is the structured result of describe_tool.
+type DescribeToolOutput struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	Namespace    string    `json:"namespace,omitempty"`
+	Version      string    `json:"version,omitempty"`
+	Title        string    `json:"title,omitempty"`
+	Description  string    `json:"description,omitempty"`
+	Tags         []string  `json:"tags,omitempty"`
+	InputSchema  any       `json:"inputSchema"`
+	OutputSchema any       `json:"outputSchema,omitempty"`
+	Backends     []Backend `json:"backends"`
+}
+
+// ListNamespacesInput is the input of the list_namespaces tool.
+type ListNamespacesInput struct {
+	Limit  int    `json:"limit,omitempty" jsonschema:"maximum number of namespaces to return"`
+	Cursor string `json:"cursor,omitempty" jsonschema:"cursor from a previous call to fetch the next page"`
+}
+
+// ListNamespacesOutput is the structured result of list_namespaces.
+type ListNamespacesOutput struct {
+	Namespaces []string `json:"namespaces"`
+	NextCursor string   `json:"nextCursor,omitempty"`
+}
+
+// New returns an MCP server exposing idx through search_tools,
+// describe_tool and list_namespaces.
+func New(idx toolindex.Index, opts Options) *mcp.Server {
+	if opts.Name == "" {
+		opts.Name = "toolsearch"
+	}
+	if opts.Version == "" {
+		opts.Version = "dev"
+	}
+	if opts.DefaultLimit <= 0 {
+		opts.DefaultLimit = 10
+	}
+	if opts.MaxLimit <= 0 {
+		opts.MaxLimit = 50
+	}
+
+	h := &handlers{idx: idx, opts: opts}
+	srv := mcp.NewServer(&mcp.Implementation{Name: opts.Name, Version: opts.Version}, nil)
+	mcp.AddTool(srv, &mcp.Tool{
+		Name:        "search_tools",
+		Description: "Search the tool catalog and return ranked tool summaries. Use describe_tool for a result's full schema.",
+	}, h.searchTools)
+	mcp.AddTool(srv, &mcp.Tool{
+		Name:        "describe_tool",
+		Description: "Return the full definition, input schema and backends of a tool by ID.",
+	}, h.describeTool)
+	mcp.AddTool(srv, &mcp.Tool{
+		Name:        "list_namespaces",
+		Description: "List the namespaces in the tool catalog in alphabetical order.",
+	}, h.listNamespaces)
+	return srv
+}
+
+type handlers struct {
+	idx  toolindex.Index
+	opts Options
+}
+
+// limit applies the default and cap to a client-requested limit.
+func (h *handlers) limit(requested int) int {
+	if requested <= 0 {
+		return h.opts.DefaultLimit
+	}
+	return min(requested, h.opts.MaxLimit)
+}
+
+func (h *handlers) searchTools(_ context.Context, _ *mcp.CallToolRequest, in SearchToolsInput) (*mcp.CallToolResult, SearchToolsOutput, error) {
+	results, next, err := h.idx.SearchPage(in.Query, h.limit(in.Limit), in.Cursor)
+	if err != nil {
+		return nil, SearchToolsOutput{}, err
+	}
+	if results == nil {
+		results = []toolindex.Summary{}
+	}
+	return nil, SearchToolsOutput{Results: results, NextCursor: next}, nil
+}
+
+func (h *handlers) describeTool(_ context.Context, _ *mcp.CallToolRequest, in DescribeToolInput) (*mcp.CallToolResult, DescribeToolOutput, error) {
+	if in.ID == "" {
+		return nil, DescribeToolOutput{}, errors.New("id is required")
+	}
+	tool, _, err := h.idx.GetTool(in.ID)
+	if err != nil {
+		if errors.Is(err, toolindex.ErrNotFound) {
+			return nil, DescribeToolOutput{}, fmt.Errorf("tool %q not found", in.ID)
+		}
+		return nil, DescribeToolOutput{}, err
+	}
+	backends, err := h.idx.GetAllBackends(in.ID)
+	if err != nil {
+		return nil, DescribeToolOutput{}, err
+	}
+
+	out := DescribeToolOutput{
+		ID:           tool.ToolID(),
+		Name:         tool.Name,
+		Namespace:    tool.Namespace,
+		Version:      tool.Version,
+		Title:        tool.Title,
+		Description:  tool.Description,
+		Tags:         tool.Tags,
+		InputSchema:  tool.InputSchema,
+		OutputSchema: tool.OutputSchema,
+		Backends:     make([]Backend, 0, len(backends)),
+	}
+	for _, b := range backends {
+		out.Backends = append(out.Backends, backendInfo(b))
+	}
+	return nil, out, nil
+}
+
+func (h *handlers) listNamespaces(_ context.Context, _ *mcp.CallToolRequest, in ListNamespacesInput) (*mcp.CallToolResult, ListNamespacesOutput, error) {
+	namespaces, next, err := h.idx.ListNamespacesPage(h.limit(in.Limit), in.Cursor)
+	if err != nil {
+		return nil, ListNamespacesOutput{}, err
+	}
+	if namespaces == nil {
+		namespaces = []string{}
+	}
+	return nil, ListNamespacesOutput{Namespaces: namespaces, NextCursor: next}, nil
+}
+
+// backendInfo reduces a backend to its kind and identifying name.
+func backendInfo(b toolmodel.ToolBackend) Backend {
+	info := Backend{Kind: string(b.Kind)}
+	switch {
+	case b.MCP != nil:
+		info.ID = b.MCP.ServerName
+	case b.Provider != nil:
+		info.ID = b.Provider.ProviderID + ":" + b.Provider.ToolID
+	case b.Local != nil:
+		info.ID = b.Local.Name
+	}
+	return info
+}
@@ -0,0 +1,165 @@
+package mcpserver_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/jonwraymond/toolindex"
+	"github.com/jonwraymond/toolmodel"
+	"github.com/jonwraymond/toolsearch"
+	"github.com/jonwraymond/toolsearch/mcpserver"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func newTestIndex(t *testing.T) toolindex.Index {
+	t.Helper()
+	idx := toolindex.NewInMemoryIndex(toolindex.IndexOptions{
+		Searcher: toolsearch.NewBM25Searcher(toolsearch.BM25Config{}),
+	})
+	tools := []struct{ name, desc, ns string }{
+		{"git_status", "Show the working tree status", "git"},
+		{"git_commit", "Record changes to the repository", "git"},
+		{"docker_ps", "List running containers", "docker"},
+		{"kubectl_get", "Display one or many resources", "kubectl"},
+	}
+	for _, tt := range tools {
+		tool := toolmodel.Tool{
+			Tool: mcp.Tool{
+				Name:        tt.name,
+				Description: tt.desc,
+				InputSchema: map[string]any{"type": "object"},
+			},
+			Namespace: tt.ns,
+			Tags:      []string{tt.ns},
+		}
+		backend := toolmodel.ToolBackend{Kind: toolmodel.BackendKindMCP, MCP: &toolmodel.MCPBackend{ServerName: tt.ns + "-mcp"}}
+		if err := idx.RegisterTool(tool, backend); err != nil {
+			t.Fatalf("register %s: %v", tt.name, err)
+		}
+	}
+	return idx
+}
+
+func connect(t *testing.T, opts mcpserver.Options) *mcp.ClientSession {
+	t.Helper()
+	ctx := context.Background()
+	srv := mcpserver.New(newTestIndex(t), opts)
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	ss, err := srv.Connect(ctx, serverTransport, nil)
+	if err != nil {
+		t.Fatalf("server connect: %v", err)
+	}
+	t.Cleanup(func() { _ = ss.Close() })
+
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "v0"}, nil)
+	cs, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatalf("client connect: %v", err)
+	}
+	t.Cleanup(func() { _ = cs.Close() })
+	return cs
+}
+
+func callTool[T any](t *testing.T, cs *mcp.ClientSession, name string, args map[string]any) (T, *mcp.CallToolResult) {
+	t.Helper()
+	var out T
+	res, err := cs.CallTool(context.Background(), &mcp.CallToolParams{Name: name, Arguments: args})
+	if err != nil {
+		t.Fatalf("CallTool(%s) error: %v", name, err)
+	}
+	if res.IsError {
+		return out, res
+	}
+	data, err := json.Marshal(res.StructuredContent)
+	if err != nil {
+		t.Fatalf("marshal structured content: %v", err)
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("decode structured content: %v", err)
+	}
+	return out, res
+}
+
+func TestServer_ListsTools(t *testing.T) {
+	cs := connect(t, mcpserver.Options{})
+	res, err := cs.ListTools(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("ListTools error: %v", err)
+	}
+	names := map[string]bool{}
+	for _, tool := range res.Tools {
+		names[tool.Name] = true
+		if tool.OutputSchema == nil {
+			t.Errorf("tool %s has no output schema", tool.Name)
+		}
+	}
+	for _, want := range []string{"search_tools", "describe_tool", "list_namespaces"} {
+		if !names[want] {
+			t.Errorf("missing tool %s", want)
+		}
+	}
+}
+
+func TestServer_SearchTools(t *testing.T) {
+	cs := connect(t, mcpserver.Options{})
+
+	out, res := callTool[mcpserver.SearchToolsOutput](t, cs, "search_tools", map[string]any{"query": "containers"})
+	if res.IsError {
+		t.Fatalf("search_tools failed: %+v", res.Content)
+	}
+	if len(out.Results) == 0 || out.Results[0].ID != "docker:docker_ps" {
+		t.Errorf("unexpected results: %+v", out.Results)
+	}
+}
+
+func TestServer_SearchTools_PagingAndLimitCap(t *testing.T) {
+	cs := connect(t, mcpserver.Options{MaxLimit: 2})
+
+	first, _ := callTool[mcpserver.SearchToolsOutput](t, cs, "search_tools", map[string]any{"query": "", "limit": 100})
+	if len(first.Results) != 2 || first.NextCursor == "" {
+		t.Fatalf("expected capped first page with cursor, got %+v", first)
+	}
+	second, _ := callTool[mcpserver.SearchToolsOutput](t, cs, "search_tools", map[string]any{"query": "", "limit": 2, "cursor": first.NextCursor})
+	if len(second.Results) != 2 || second.Results[0].ID == first.Results[0].ID {
+		t.Errorf("unexpected second page: %+v", second)
+	}
+}
+
+func TestServer_DescribeTool(t *testing.T) {
+	cs := connect(t, mcpserver.Options{})
+
+	out, res := callTool[mcpserver.DescribeToolOutput](t, cs, "describe_tool", map[string]any{"id": "git:git_commit"})
+	if res.IsError {
+		t.Fatalf("describe_tool failed: %+v", res.Content)
+	}
+	if out.Name != "git_commit" || out.Namespace != "git" || out.InputSchema == nil {
+		t.Errorf("unexpected description: %+v", out)
+	}
+	if len(out.Backends) != 1 || out.Backends[0] != (mcpserver.Backend{Kind: "mcp", ID: "git-mcp"}) {
+		t.Errorf("unexpected backends: %+v", out.Backends)
+	}
+
+	_, res = callTool[mcpserver.DescribeToolOutput](t, cs, "describe_tool", map[string]any{"id": "missing:tool"})
+	if !res.IsError {
+		t.Error("expected tool error for unknown ID")
+	}
+}
+
+func TestServer_ListNamespaces(t *testing.T) {
+	cs := connect(t, mcpserver.Options{})
+
+	out, res := callTool[mcpserver.ListNamespacesOutput](t, cs, "list_namespaces", nil)
+	if res.IsError {
+		t.Fatalf("list_namespaces failed: %+v", res.Content)
+	}
+	want := []string{"docker", "git", "kubectl"}
+	if len(out.Namespaces) != len(want) {
+		t.Fatalf("got %v, want %v", out.Namespaces, want)
+	}
+	for i := range want {
+		if out.Namespaces[i] != want[i] {
+			t.Errorf("namespace %d = %s, want %s", i, out.Namespaces[i], want[i])
+		}
+	}
+}
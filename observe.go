@@ -0,0 +1,207 @@
+package toolsearch
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jonwraymond/toolindex"
+)
+
+// Observer receives BM25Searcher events for metrics, tracing and logs.
+// Callbacks run synchronously on searching goroutines and on background
+// rebuilds, so they must be fast and safe for concurrent use. Embed
+// NopObserver to implement only some of them.
+type Observer interface {
+	// SearchStart is called when a search begins. The returned context
+	// is passed to the search's other callbacks, so tracers can attach a
+	// span to it.
+	SearchStart(ctx context.Context, e SearchStartEvent) context.Context
+	SearchEnd(ctx context.Context, e SearchEndEvent)
+
+	// RebuildStart is called before an index build for a new catalog
+	// fingerprint. The returned context is passed to RebuildEnd.
+	RebuildStart(ctx context.Context, e RebuildStartEvent) context.Context
+	RebuildEnd(ctx context.Context, e RebuildEndEvent)
+
+	// CacheHit is called when a search is served from the query cache.
+	CacheHit(ctx context.Context, e CacheHitEvent)
+
+	// Error is called for every failed search and rebuild, including
+	// background rebuilds no search waits for.
+	Error(ctx context.Context, e ErrorEvent)
+}
+
+// SearchStartEvent describes a search as requested.
+type SearchStartEvent struct {
+	Query string
+	Limit int
+	Docs  int // docs passed in
+}
+
+// SearchEndEvent describes a finished search.
+type SearchEndEvent struct {
+	Query    string
+	Limit    int
+	Hits     int
+	Stale    bool // served from a previous index during an async rebuild
+	Duration time.Duration
+	Err      error
+}
+
+// RebuildStartEvent describes an index build about to start.
+type RebuildStartEvent struct {
+	Fingerprint         string
+	PreviousFingerprint string // empty for the first build
+	Docs                int    // docs to index, after truncation
+	Background          bool   // an AsyncRebuild build no search waits for
+}
+
+// RebuildEndEvent describes a finished index build.
+type RebuildEndEvent struct {
+	Fingerprint string
+	Docs        int
+	Background  bool
+	Duration    time.Duration
+	Err         error
+}
+
+// CacheHitEvent describes a search served from the query cache.
+type CacheHitEvent struct {
+	Query string
+	Limit int
+	Hits  int
+}
+
+// Operations reported in ErrorEvent.
+const (
+	OpSearch  = "search"
+	OpRebuild = "rebuild"
+)
+
+// ErrorEvent describes a failed operation.
+type ErrorEvent struct {
+	Op  string // OpSearch or OpRebuild
+	Err error
+}
+
+// NopObserver ignores every event.
+type NopObserver struct{}
+
+func (NopObserver) SearchStart(ctx context.Context, _ SearchStartEvent) context.Context {
+	return ctx
+}
+
+func (NopObserver) SearchEnd(context.Context, SearchEndEvent) {}
+
+func (NopObserver) RebuildStart(ctx context.Context, _ RebuildStartEvent) context.Context {
+	return ctx
+}
+
+func (NopObserver) RebuildEnd(context.Context, RebuildEndEvent) {}
+
+func (NopObserver) CacheHit(context.Context, CacheHitEvent) {}
+
+func (NopObserver) Error(context.Context, ErrorEvent) {}
+
+// NewSlogObserver returns an Observer that logs to logger: rebuilds at
+// Info, searches and cache hits at Debug, and errors at Error. Queries
+// are not logged.
+func NewSlogObserver(logger *slog.Logger) Observer {
+	return &slogObserver{logger: logger}
+}
+
+type slogObserver struct {
+	NopObserver
+	logger *slog.Logger
+}
+
+func (o *slogObserver) SearchEnd(ctx context.Context, e SearchEndEvent) {
+	o.logger.DebugContext(ctx, "toolsearch search",
+		slog.Int("limit", e.Limit),
+		slog.Int("hits", e.Hits),
+		slog.Bool("stale", e.Stale),
+		slog.Duration("duration", e.Duration))
+}
+
+func (o *slogObserver) RebuildEnd(ctx context.Context, e RebuildEndEvent) {
+	if e.Err != nil {
+		return // logged by Error
+	}
+	o.logger.InfoContext(ctx, "toolsearch rebuild",
+		slog.String("fingerprint", e.Fingerprint),
+		slog.Int("docs", e.Docs),
+		slog.Bool("background", e.Background),
+		slog.Duration("duration", e.Duration))
+}
+
+func (o *slogObserver) CacheHit(ctx context.Context, e CacheHitEvent) {
+	o.logger.DebugContext(ctx, "toolsearch cache hit", slog.Int("limit", e.Limit), slog.Int("hits", e.Hits))
+}
+
+func (o *slogObserver) Error(ctx context.Context, e ErrorEvent) {
+	o.logger.ErrorContext(ctx, "toolsearch "+e.Op+" failed", slog.Any("error", e.Err))
+}
+
+// observedSearch runs a search with Observer callbacks around it and logs
+// it to the QueryLog.
+func (s *BM25Searcher) observedSearch(query string, limit int, docs []toolindex.SearchDoc, opts SearchOptions, live bool) (SearchResult, error) {
+	obs := s.cfg.Observer
+	if obs != nil {
+		opts.Context = obs.SearchStart(opts.context(), SearchStartEvent{Query: query, Limit: limit, Docs: len(docs)})
+	}
+	start := time.Now()
+	result, err := s.searchDocs(query, limit, docs, opts, live)
+	elapsed := time.Since(start)
+
+	if obs != nil {
+		ctx := opts.Context
+		if err != nil {
+			obs.Error(ctx, ErrorEvent{Op: OpSearch, Err: err})
+		}
+		obs.SearchEnd(ctx, SearchEndEvent{
+			Query:    query,
+			Limit:    limit,
+			Hits:     len(result.Hits),
+			Stale:    result.Stale,
+			Duration: elapsed,
+			Err:      err,
+		})
+	}
+	if s.cfg.QueryLog != nil {
+		s.cfg.QueryLog.logSearch(query, limit, opts, result, elapsed, err)
+	}
+	return result, err
+}
+
+// observeRebuild runs build, an index build of docs for fingerprint, with
+// Observer callbacks around it.
+func (s *BM25Searcher) observeRebuild(ctx context.Context, docs int, fingerprint string, background bool, build func() error) error {
+	obs := s.cfg.Observer
+	if obs == nil {
+		return build()
+	}
+	s.mu.RLock()
+	previous := s.lastFingerprint
+	s.mu.RUnlock()
+
+	ctx = obs.RebuildStart(ctx, RebuildStartEvent{
+		Fingerprint:         fingerprint,
+		PreviousFingerprint: previous,
+		Docs:                docs,
+		Background:          background,
+	})
+	start := time.Now()
+	err := build()
+	if err != nil {
+		obs.Error(ctx, ErrorEvent{Op: OpRebuild, Err: err})
+	}
+	obs.RebuildEnd(ctx, RebuildEndEvent{
+		Fingerprint: fingerprint,
+		Docs:        docs,
+		Background:  background,
+		Duration:    time.Since(start),
+		Err:         err,
+	})
+	return err
+}
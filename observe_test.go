@@ -0,0 +1,142 @@
+package toolsearch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type ctxKey struct{}
+
+// recordingObserver records event names, checking that each search's
+// context reaches its callbacks.
+type recordingObserver struct {
+	mu     sync.Mutex
+	events []string
+	errs   []ErrorEvent
+}
+
+func (o *recordingObserver) record(ctx context.Context, event string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if ctx.Value(ctxKey{}) == nil {
+		event += " (no context)"
+	}
+	o.events = append(o.events, event)
+}
+
+func (o *recordingObserver) take() []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	events := o.events
+	o.events = nil
+	return events
+}
+
+func (o *recordingObserver) SearchStart(ctx context.Context, _ SearchStartEvent) context.Context {
+	ctx = context.WithValue(ctx, ctxKey{}, true)
+	o.record(ctx, "search start")
+	return ctx
+}
+
+func (o *recordingObserver) SearchEnd(ctx context.Context, _ SearchEndEvent) {
+	o.record(ctx, "search end")
+}
+
+func (o *recordingObserver) RebuildStart(ctx context.Context, e RebuildStartEvent) context.Context {
+	if e.Background {
+		ctx = context.WithValue(ctx, ctxKey{}, true)
+	}
+	o.record(ctx, "rebuild start")
+	return ctx
+}
+
+func (o *recordingObserver) RebuildEnd(ctx context.Context, _ RebuildEndEvent) {
+	o.record(ctx, "rebuild end")
+}
+
+func (o *recordingObserver) CacheHit(ctx context.Context, _ CacheHitEvent) {
+	o.record(ctx, "cache hit")
+}
+
+func (o *recordingObserver) Error(ctx context.Context, e ErrorEvent) {
+	o.record(ctx, "error "+e.Op)
+	o.mu.Lock()
+	o.errs = append(o.errs, e)
+	o.mu.Unlock()
+}
+
+func TestObserver_Events(t *testing.T) {
+	obs := &recordingObserver{}
+	s := NewBM25Searcher(BM25Config{Observer: obs, CacheSize: 8, MaxIndexBytes: fullIndexBytes(t, 10)})
+	defer func() { _ = s.Close() }()
+
+	search := func(docs int) {
+		_, _ = s.Search("tool", 5, makeTestDocs(docs))
+	}
+	search(10)
+	if got, want := obs.take(), []string{"search start", "rebuild start", "rebuild end", "search end"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("first search events = %v, want %v", got, want)
+	}
+	search(10)
+	if got, want := obs.take(), []string{"search start", "cache hit", "search end"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("repeated search events = %v, want %v", got, want)
+	}
+	search(20)
+	if got, want := obs.take(), []string{"search start", "error search", "search end"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("over-budget search events = %v, want %v", got, want)
+	}
+	if len(obs.errs) != 1 || !errors.Is(obs.errs[0].Err, ErrIndexBudget) {
+		t.Errorf("errors = %+v, want one ErrIndexBudget", obs.errs)
+	}
+}
+
+func TestObserver_BackgroundRebuild(t *testing.T) {
+	obs := &recordingObserver{}
+	s := NewBM25Searcher(BM25Config{Observer: obs, AsyncRebuild: true})
+	defer func() { _ = s.Close() }()
+
+	if _, err := s.Search("shared", 10, asyncDocs("a", "b")); err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	obs.take()
+	if _, err := s.Search("shared", 10, asyncDocs("a", "b", "c")); err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	if err := s.WaitFresh(context.Background()); err != nil {
+		t.Fatalf("WaitFresh error: %v", err)
+	}
+	got := obs.take()
+	slices.Sort(got)
+	if want := []string{"rebuild end", "rebuild start", "search end", "search start"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %v, want %v with contexts", got, want)
+	}
+}
+
+func TestSlogObserver(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	s := NewBM25Searcher(BM25Config{Observer: NewSlogObserver(logger), CacheSize: 8})
+	defer func() { _ = s.Close() }()
+
+	for range 2 {
+		if _, err := s.Search("secret query", 5, makeTestDocs(5)); err != nil {
+			t.Fatalf("search error: %v", err)
+		}
+	}
+	out := buf.String()
+	for _, want := range []string{`msg="toolsearch rebuild"`, "docs=5", `msg="toolsearch search"`, `msg="toolsearch cache hit"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "secret") {
+		t.Errorf("log contains the query:\n%s", out)
+	}
+}
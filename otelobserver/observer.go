@@ -0,0 +1,131 @@
+// Package otelobserver reports toolsearch events as OpenTelemetry traces
+// and metrics.
+//
+// Searches become "toolsearch.search" spans, with index builds they run
+// as "toolsearch.rebuild" child spans. Background rebuilds start their own
+// traces. Metrics:
+//
+//   - toolsearch.search.duration     histogram (s), attribute stale
+//   - toolsearch.rebuild.duration    histogram (s), attribute background
+//   - toolsearch.fingerprint.changes counter of rebuilds replacing an index
+//   - toolsearch.cache.hits          counter
+//   - toolsearch.errors              counter, attribute op
+//
+// Queries are not recorded.
+//
+//	obs, err := otelobserver.New(otel.GetTracerProvider(), otel.GetMeterProvider())
+//	searcher := toolsearch.NewBM25Searcher(toolsearch.BM25Config{Observer: obs})
+package otelobserver
+
+import (
+	"context"
+
+	"github.com/jonwraymond/toolsearch"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const scope = "github.com/jonwraymond/toolsearch"
+
+// Observer is a toolsearch.Observer recording spans and metrics.
+type Observer struct {
+	tracer trace.Tracer
+
+	searchDuration  metric.Float64Histogram
+	rebuildDuration metric.Float64Histogram
+	changes         metric.Int64Counter
+	cacheHits       metric.Int64Counter
+	errors          metric.Int64Counter
+}
+
+// Ensure interface compliance at compile time.
+var _ toolsearch.Observer = (*Observer)(nil)
+
+// New creates an Observer using tp and mp.
+func New(tp trace.TracerProvider, mp metric.MeterProvider) (*Observer, error) {
+	meter := mp.Meter(scope)
+	o := &Observer{tracer: tp.Tracer(scope)}
+	var err error
+	if o.searchDuration, err = meter.Float64Histogram("toolsearch.search.duration",
+		metric.WithUnit("s"), metric.WithDescription("Duration of searches")); err != nil {
+		return nil, err
+	}
+	if o.rebuildDuration, err = meter.Float64Histogram("toolsearch.rebuild.duration",
+		metric.WithUnit("s"), metric.WithDescription("Duration of index builds")); err != nil {
+		return nil, err
+	}
+	if o.changes, err = meter.Int64Counter("toolsearch.fingerprint.changes",
+		metric.WithDescription("Index builds replacing an index built from other docs")); err != nil {
+		return nil, err
+	}
+	if o.cacheHits, err = meter.Int64Counter("toolsearch.cache.hits",
+		metric.WithDescription("Searches served from the query cache")); err != nil {
+		return nil, err
+	}
+	if o.errors, err = meter.Int64Counter("toolsearch.errors",
+		metric.WithDescription("Failed searches and index builds")); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// SearchStart starts a search span.
+func (o *Observer) SearchStart(ctx context.Context, e toolsearch.SearchStartEvent) context.Context {
+	ctx, _ = o.tracer.Start(ctx, "toolsearch.search", trace.WithAttributes(
+		attribute.Int("toolsearch.limit", e.Limit),
+		attribute.Int("toolsearch.docs", e.Docs),
+	))
+	return ctx
+}
+
+// SearchEnd ends the search span and records its duration.
+func (o *Observer) SearchEnd(ctx context.Context, e toolsearch.SearchEndEvent) {
+	o.searchDuration.Record(ctx, e.Duration.Seconds(),
+		metric.WithAttributes(attribute.Bool("stale", e.Stale)))
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(
+		attribute.Int("toolsearch.hits", e.Hits),
+		attribute.Bool("toolsearch.stale", e.Stale),
+	)
+	endSpan(span, e.Err)
+}
+
+// RebuildStart starts a rebuild span.
+func (o *Observer) RebuildStart(ctx context.Context, e toolsearch.RebuildStartEvent) context.Context {
+	if e.PreviousFingerprint != "" {
+		o.changes.Add(ctx, 1)
+	}
+	ctx, _ = o.tracer.Start(ctx, "toolsearch.rebuild", trace.WithAttributes(
+		attribute.String("toolsearch.fingerprint", e.Fingerprint),
+		attribute.Int("toolsearch.docs", e.Docs),
+		attribute.Bool("toolsearch.background", e.Background),
+	))
+	return ctx
+}
+
+// RebuildEnd ends the rebuild span and records its duration.
+func (o *Observer) RebuildEnd(ctx context.Context, e toolsearch.RebuildEndEvent) {
+	o.rebuildDuration.Record(ctx, e.Duration.Seconds(),
+		metric.WithAttributes(attribute.Bool("background", e.Background)))
+	endSpan(trace.SpanFromContext(ctx), e.Err)
+}
+
+// CacheHit counts a cache hit.
+func (o *Observer) CacheHit(ctx context.Context, e toolsearch.CacheHitEvent) {
+	o.cacheHits.Add(ctx, 1)
+}
+
+// Error counts a failure by operation.
+func (o *Observer) Error(ctx context.Context, e toolsearch.ErrorEvent) {
+	o.errors.Add(ctx, 1, metric.WithAttributes(attribute.String("op", e.Op)))
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,94 @@
+package otelobserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jonwraymond/toolindex"
+	"github.com/jonwraymond/toolsearch"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func docs(n int) []toolindex.SearchDoc {
+	out := make([]toolindex.SearchDoc, n)
+	for i := range out {
+		id := fmt.Sprintf("tool-%d", i)
+		out[i] = toolindex.SearchDoc{ID: id, DocText: "deploy service " + id, Summary: toolindex.Summary{ID: id, Name: id}}
+	}
+	return out
+}
+
+func TestObserver(t *testing.T) {
+	spans := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	obs, err := New(tp, mp)
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	s := toolsearch.NewBM25Searcher(toolsearch.BM25Config{Observer: obs, CacheSize: 8})
+	defer func() { _ = s.Close() }()
+
+	for _, n := range []int{5, 5, 6} {
+		if _, err := s.Search("deploy", 3, docs(n)); err != nil {
+			t.Fatalf("search error: %v", err)
+		}
+	}
+	obs.Error(context.Background(), toolsearch.ErrorEvent{Op: toolsearch.OpSearch, Err: errors.New("boom")})
+
+	ended := spans.Ended()
+	var searches, rebuilds int
+	for _, span := range ended {
+		switch span.Name() {
+		case "toolsearch.search":
+			searches++
+		case "toolsearch.rebuild":
+			rebuilds++
+			if !span.Parent().IsValid() {
+				t.Errorf("rebuild span has no parent search span")
+			}
+		}
+	}
+	if searches != 3 || rebuilds != 2 {
+		t.Errorf("got %d search and %d rebuild spans, want 3 and 2", searches, rebuilds)
+	}
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect error: %v", err)
+	}
+	got := map[string]int64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				for _, p := range data.DataPoints {
+					got[m.Name] += p.Value
+				}
+			case metricdata.Histogram[float64]:
+				for _, p := range data.DataPoints {
+					got[m.Name] += int64(p.Count)
+				}
+			}
+		}
+	}
+	want := map[string]int64{
+		"toolsearch.search.duration":     3,
+		"toolsearch.rebuild.duration":    2,
+		"toolsearch.fingerprint.changes": 1,
+		"toolsearch.cache.hits":          1,
+		"toolsearch.errors":              1,
+	}
+	for name, n := range want {
+		if got[name] != n {
+			t.Errorf("%s = %d, want %d", name, got[name], n)
+		}
+	}
+}
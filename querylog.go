@@ -0,0 +1,202 @@
+package toolsearch
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// QueryRecord is one line of a query log. Its query, shown and selected
+// fields are those of SelectionRecord, so ReadSelectionLog, LearnBoosts
+// and eval.JudgmentsFromSelections read a query log directly; search
+// records, which have no selection, are skipped as unusable.
+type QueryRecord struct {
+	Time        time.Time `json:"time"`
+	Session     string    `json:"session,omitempty"`
+	Query       string    `json:"query"`
+	Normalized  string    `json:"normalized"`
+	Limit       int       `json:"limit,omitempty"`
+	Shown       []string  `json:"shown"`
+	Scores      []float64 `json:"scores,omitempty"`
+	LatencyMS   float64   `json:"latency_ms,omitempty"`
+	Fingerprint string    `json:"fingerprint,omitempty"` // of the index that served the search
+	Stale       bool      `json:"stale,omitempty"`
+	Error       string    `json:"error,omitempty"`
+
+	// Selected is set on records written by LogSelection.
+	Selected string `json:"selected,omitempty"`
+}
+
+// QueryLogOptions configures a QueryLogger.
+type QueryLogOptions struct {
+	// Sample decides whether a record is written; nil writes every
+	// record. It sees records before redaction. Use SampleRate for a
+	// sample that keeps a search and its selections together.
+	Sample func(QueryRecord) bool
+
+	// Redact rewrites a record before it is written, for example to mask
+	// personal data in queries with RedactQuery.
+	Redact func(*QueryRecord)
+}
+
+// QueryLogger writes searches and selections as JSON lines, for offline
+// analysis of what agents search for. Set it as BM25Config.QueryLog. It is
+// safe for concurrent use.
+type QueryLogger struct {
+	opts QueryLogOptions
+	now  func() time.Time
+
+	mu  sync.Mutex
+	w   *bufio.Writer
+	err error
+}
+
+// NewQueryLogger returns a logger writing to w. Lines are buffered; call
+// Flush before reading w.
+func NewQueryLogger(w io.Writer, opts QueryLogOptions) *QueryLogger {
+	return &QueryLogger{opts: opts, now: time.Now, w: bufio.NewWriter(w)}
+}
+
+// LogSelection records that the caller picked selected from shown, the
+// results of query. Pass the session of the search so sampling keeps or
+// drops both.
+func (l *QueryLogger) LogSelection(session, query string, shown []string, selected string) {
+	l.write(QueryRecord{
+		Session:    session,
+		Query:      query,
+		Normalized: normalizeQuery(query),
+		Shown:      shown,
+		Selected:   selected,
+	})
+}
+
+// logSearch records a finished search. Empty queries list the catalog
+// rather than search it, so they are not logged.
+func (l *QueryLogger) logSearch(query string, limit int, opts SearchOptions, result SearchResult, latency time.Duration, err error) {
+	normalized := normalizeQuery(query)
+	if normalized == "" {
+		return
+	}
+	rec := QueryRecord{
+		Session:     opts.Session,
+		Query:       query,
+		Normalized:  normalized,
+		Limit:       limit,
+		Shown:       make([]string, len(result.Hits)),
+		Scores:      make([]float64, len(result.Hits)),
+		LatencyMS:   float64(latency.Microseconds()) / 1000,
+		Fingerprint: result.fingerprint,
+		Stale:       result.Stale,
+	}
+	for i, hit := range result.Hits {
+		rec.Shown[i] = hit.Summary.ID
+		rec.Scores[i] = hit.Score
+	}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	l.write(rec)
+}
+
+func (l *QueryLogger) write(rec QueryRecord) {
+	if l.opts.Sample != nil && !l.opts.Sample(rec) {
+		return
+	}
+	if l.opts.Redact != nil {
+		l.opts.Redact(&rec)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil {
+		return
+	}
+	rec.Time = l.now().UTC()
+	line, err := json.Marshal(rec)
+	if err == nil {
+		line = append(line, '\n')
+		_, err = l.w.Write(line)
+	}
+	l.err = err
+}
+
+// Flush writes buffered records to the underlying writer.
+func (l *QueryLogger) Flush() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil {
+		return l.err
+	}
+	l.err = l.w.Flush()
+	return l.err
+}
+
+// Err returns the first write error. Logging stops after an error;
+// searches are never failed by the log.
+func (l *QueryLogger) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
+// SampleRate returns a Sample function keeping about rate (0 to 1) of
+// records. The decision hashes the session and normalized query, so a
+// search and its selection are kept or dropped together, and records
+// without a session are sampled by query.
+func SampleRate(rate float64) func(QueryRecord) bool {
+	threshold := uint64(math.Max(0, math.Min(1, rate)) * math.MaxUint32)
+	return func(rec QueryRecord) bool {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(rec.Session))
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write([]byte(rec.Normalized))
+		return uint64(h.Sum32()) < threshold
+	}
+}
+
+// RedactQuery returns a Redact function replacing matches of re in the
+// query and normalized query with repl, as regexp.ReplaceAllString does.
+func RedactQuery(re *regexp.Regexp, repl string) func(*QueryRecord) {
+	return func(rec *QueryRecord) {
+		rec.Query = re.ReplaceAllString(rec.Query, repl)
+		rec.Normalized = re.ReplaceAllString(rec.Normalized, repl)
+	}
+}
+
+// ReadQueryLog decodes a query log. Blank lines are skipped; a malformed
+// line fails with its line number.
+func ReadQueryLog(r io.Reader) ([]QueryRecord, error) {
+	var records []QueryRecord
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var rec QueryRecord
+		if err := json.Unmarshal([]byte(text), &rec); err != nil {
+			return nil, fmt.Errorf("query log line %d: %w", line, err)
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read query log: %w", err)
+	}
+	return records, nil
+}
+
+// normalizeQuery lowercases query and collapses whitespace, as searches
+// and the query cache do.
+func normalizeQuery(query string) string {
+	return strings.Join(strings.Fields(strings.ToLower(query)), " ")
+}
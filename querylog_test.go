@@ -0,0 +1,148 @@
+package toolsearch
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestQueryLogger_RecordsSearches(t *testing.T) {
+	var buf bytes.Buffer
+	log := NewQueryLogger(&buf, QueryLogOptions{})
+	log.now = func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) }
+	s := NewBM25Searcher(BM25Config{QueryLog: log})
+	defer func() { _ = s.Close() }()
+	docs := makeLearnDocs()
+
+	result, err := s.SearchWithOptions("  Deploy  ", 5, docs, SearchOptions{Session: "s1"})
+	if err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	if _, err := s.SearchWithOptions("kubernetes", 5, docs, SearchOptions{}); err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	// Browsing the catalog is not logged.
+	if _, err := s.SearchWithOptions("  ", 5, docs, SearchOptions{}); err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	log.LogSelection("s1", "  Deploy  ", []string{"ops:history", "ops:deploy"}, "ops:deploy")
+	if err := log.Flush(); err != nil {
+		t.Fatalf("Flush error: %v", err)
+	}
+
+	records, err := ReadQueryLog(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadQueryLog error: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("got %d records, want 3:\n%s", len(records), buf.String())
+	}
+	first := records[0]
+	if first.Session != "s1" || first.Query != "  Deploy  " || first.Normalized != "deploy" || first.Limit != 5 ||
+		first.Fingerprint != computeFingerprint(sortDocsByID(docs)) || !first.Time.Equal(log.now()) {
+		t.Errorf("search record = %+v", first)
+	}
+	if want := []string{result.Hits[0].Summary.ID, result.Hits[1].Summary.ID}; !reflect.DeepEqual(first.Shown, want) ||
+		len(first.Scores) != 2 || first.Scores[0] != result.Hits[0].Score {
+		t.Errorf("shown %v scores %v, want %v with scores", first.Shown, first.Scores, want)
+	}
+	if len(records[1].Shown) != 0 {
+		t.Errorf("zero-result record = %+v", records[1])
+	}
+	if records[2].Selected != "ops:deploy" || records[2].Normalized != "deploy" {
+		t.Errorf("selection record = %+v", records[2])
+	}
+}
+
+func TestQueryLogger_FeedsLearnBoosts(t *testing.T) {
+	var buf bytes.Buffer
+	log := NewQueryLogger(&buf, QueryLogOptions{})
+	s := NewBM25Searcher(BM25Config{QueryLog: log})
+	defer func() { _ = s.Close() }()
+	docs := makeLearnDocs()
+
+	for i := range 2 {
+		session := fmt.Sprint(i)
+		result, err := s.SearchWithOptions("deploy", 5, docs, SearchOptions{Session: session})
+		if err != nil {
+			t.Fatalf("search error: %v", err)
+		}
+		shown := make([]string, len(result.Hits))
+		for j, hit := range result.Hits {
+			shown[j] = hit.Summary.ID
+		}
+		log.LogSelection(session, "deploy", shown, "ops:deploy")
+	}
+	if err := log.Flush(); err != nil {
+		t.Fatalf("Flush error: %v", err)
+	}
+
+	records, err := ReadSelectionLog(&buf)
+	if err != nil {
+		t.Fatalf("ReadSelectionLog error: %v", err)
+	}
+	learned, err := LearnBoosts(docs, records, LearnOptions{})
+	if err != nil {
+		t.Fatalf("LearnBoosts error: %v", err)
+	}
+	if learned.Records != 2 || learned.Skipped != 2 || learned.LoggedMRR != 0.5 {
+		t.Errorf("LearnBoosts = %+v, want 2 selections ranked second and 2 search records skipped", learned)
+	}
+}
+
+func TestQueryLogger_SampleAndRedact(t *testing.T) {
+	var buf bytes.Buffer
+	log := NewQueryLogger(&buf, QueryLogOptions{
+		Sample: SampleRate(0.5),
+		Redact: RedactQuery(regexp.MustCompile(`\S+@\S+`), "<email>"),
+	})
+	for i := range 200 {
+		session := fmt.Sprint(i)
+		log.write(QueryRecord{Session: session, Query: "mail bob@example.com", Normalized: "mail bob@example.com"})
+		log.LogSelection(session, "mail bob@example.com", nil, "mail:send")
+	}
+	if err := log.Flush(); err != nil {
+		t.Fatalf("Flush error: %v", err)
+	}
+	records, err := ReadQueryLog(&buf)
+	if err != nil {
+		t.Fatalf("ReadQueryLog error: %v", err)
+	}
+	if n := len(records); n < 120 || n > 280 || n%2 != 0 {
+		t.Errorf("kept %d of 400 records, want about half in search/selection pairs", n)
+	}
+	for i := 0; i+1 < len(records); i += 2 {
+		if records[i].Session != records[i+1].Session {
+			t.Fatalf("sampling split session %s from its selection", records[i].Session)
+		}
+	}
+	for _, rec := range records {
+		if strings.Contains(rec.Query+rec.Normalized, "bob") || rec.Normalized != "mail <email>" {
+			t.Fatalf("record not redacted: %+v", rec)
+		}
+	}
+}
+
+func TestQueryLogger_WriteErrorDoesNotFailSearch(t *testing.T) {
+	log := NewQueryLogger(failingWriter{}, QueryLogOptions{})
+	s := NewBM25Searcher(BM25Config{QueryLog: log})
+	defer func() { _ = s.Close() }()
+
+	for range 2 {
+		if _, err := s.Search("deploy", 5, makeLearnDocs()); err != nil {
+			t.Fatalf("search error: %v", err)
+		}
+	}
+	if err := log.Flush(); err == nil || log.Err() == nil {
+		t.Errorf("Flush error = %v, want the write error", err)
+	}
+}
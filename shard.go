@@ -0,0 +1,389 @@
+package toolsearch
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sync"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/index/upsidedown"
+	"github.com/blevesearch/bleve/v2/registry"
+	index "github.com/blevesearch/bleve_index_api"
+	store "github.com/blevesearch/upsidedown_store_api"
+	"github.com/jonwraymond/toolindex"
+)
+
+// Sharded indexes split a catalog by doc ID hash across several in-memory
+// Bleve indexes, built and searched in parallel through a Bleve index
+// alias. The alias scores each shard on its own, and Bleve's global
+// scoring pre-search only covers its BM25 model, not the tf-idf scoring
+// of these indexes. Each shard therefore uses the shardIndex type below,
+// whose readers report document and term counts summed over all shards
+// of its catalog: idf, query norms and scores come out exactly as from
+// one index holding the whole catalog. A search reads the group's counts
+// once and shares them between its shards.
+
+const (
+	shardIndexType = "toolsearch_shard"
+	shardGroupKey  = "toolsearch_shard_group" // store config key of the *shardGroup
+)
+
+// registerShardIndex registers the shard index type with Bleve on first
+// use.
+var registerShardIndex = sync.OnceValue(func() error {
+	return registry.RegisterIndexType(shardIndexType, newShardIndex)
+})
+
+// shardGroup is the set of shards of one catalog.
+type shardGroup struct {
+	shards []*shardIndex
+}
+
+// shardIndex is an upside_down index that is one shard of a group.
+type shardIndex struct {
+	index.Index
+	group *shardGroup
+}
+
+func newShardIndex(storeName string, storeConfig map[string]interface{}, analysisQueue *index.AnalysisQueue) (index.Index, error) {
+	group, ok := storeConfig[shardGroupKey].(*shardGroup)
+	if !ok {
+		return nil, fmt.Errorf("%s: missing shard group", shardIndexType)
+	}
+	inner, err := upsidedown.NewUpsideDownCouch(storeName, storeConfig, analysisQueue)
+	if err != nil {
+		return nil, err
+	}
+	shard := &shardIndex{Index: inner, group: group}
+	group.shards = append(group.shards, shard)
+	return shard, nil
+}
+
+// Advanced returns the shard's KV store.
+func (x *shardIndex) Advanced() (store.KVStore, error) {
+	return x.Index.(*upsidedown.UpsideDownCouch).Advanced()
+}
+
+// Reader returns a reader on this shard that reports the group's document
+// and term counts.
+func (x *shardIndex) Reader() (index.IndexReader, error) {
+	r, err := x.Index.Reader()
+	if err != nil {
+		return nil, err
+	}
+	return &shardReader{IndexReader: r, group: x.group}, nil
+}
+
+// groupStatsKey is the context key of the *groupStats of a search.
+type groupStatsKey struct{}
+
+// groupStats are the document and term counts of a shard group, read
+// once per search and shared by the readers of all its shards.
+type groupStats struct {
+	group    *shardGroup
+	readers  []index.IndexReader // one per shard
+	docCount uint64
+
+	mu     sync.Mutex
+	counts map[string]uint64 // docs containing a term, by field and term
+}
+
+func (g *shardGroup) openStats() (*groupStats, error) {
+	st := &groupStats{group: g, counts: make(map[string]uint64)}
+	for _, shard := range g.shards {
+		r, err := shard.Index.Reader()
+		if err != nil {
+			_ = st.Close()
+			return nil, err
+		}
+		st.readers = append(st.readers, r)
+		n, err := r.DocCount()
+		if err != nil {
+			_ = st.Close()
+			return nil, err
+		}
+		st.docCount += n
+	}
+	return st, nil
+}
+
+// termCount returns the number of docs in the group containing term in
+// field. Each term is counted once, for the first shard that asks.
+func (st *groupStats) termCount(ctx context.Context, term []byte, field string) (uint64, error) {
+	key := field + "\x00" + string(term)
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if n, ok := st.counts[key]; ok {
+		return n, nil
+	}
+	var n uint64
+	for _, r := range st.readers {
+		tfr, err := r.TermFieldReader(ctx, term, field, false, false, false)
+		if err != nil {
+			return 0, err
+		}
+		n += tfr.Count()
+		if err := tfr.Close(); err != nil {
+			return 0, err
+		}
+	}
+	st.counts[key] = n
+	return n, nil
+}
+
+func (st *groupStats) Close() error {
+	var first error
+	for _, r := range st.readers {
+		if err := r.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// shardReader reads one shard with group-wide statistics: those of the
+// search in progress, or its own when used outside a shardedIndex search.
+type shardReader struct {
+	index.IndexReader // this shard
+	group             *shardGroup
+	stats             *groupStats
+	ownStats          bool // stats opened by this reader
+}
+
+func (r *shardReader) groupStats(ctx context.Context) (*groupStats, error) {
+	if r.stats != nil {
+		return r.stats, nil
+	}
+	if st, ok := ctx.Value(groupStatsKey{}).(*groupStats); ok && st.group == r.group {
+		r.stats = st
+		return st, nil
+	}
+	st, err := r.group.openStats()
+	if err != nil {
+		return nil, err
+	}
+	r.stats, r.ownStats = st, true
+	return st, nil
+}
+
+// DocCount returns the number of docs in the group.
+func (r *shardReader) DocCount() (uint64, error) {
+	st, err := r.groupStats(context.Background())
+	if err != nil {
+		return 0, err
+	}
+	return st.docCount, nil
+}
+
+// TermFieldReader iterates this shard's postings of term; its Count is
+// the number of docs in the group containing term.
+func (r *shardReader) TermFieldReader(ctx context.Context, term []byte, field string, includeFreq, includeNorm, includeTermVectors bool) (index.TermFieldReader, error) {
+	st, err := r.groupStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	count, err := st.termCount(ctx, term, field)
+	if err != nil {
+		return nil, err
+	}
+	own, err := r.IndexReader.TermFieldReader(ctx, term, field, includeFreq, includeNorm, includeTermVectors)
+	if err != nil {
+		return nil, err
+	}
+	return &groupTermReader{TermFieldReader: own, count: count}, nil
+}
+
+func (r *shardReader) Close() error {
+	err := r.IndexReader.Close()
+	if r.ownStats {
+		if serr := r.stats.Close(); err == nil {
+			err = serr
+		}
+	}
+	return err
+}
+
+type groupTermReader struct {
+	index.TermFieldReader
+	count uint64
+}
+
+func (t *groupTermReader) Count() uint64 {
+	return t.count
+}
+
+// shardedIndex is an alias over the shards of one catalog. Unlike a plain
+// alias, it owns and closes its shards.
+type shardedIndex struct {
+	bleve.IndexAlias
+	shards []bleve.Index
+	group  *shardGroup
+}
+
+// DocCount returns the number of docs in all shards. The alias would sum
+// the group-wide counts of each shard.
+func (x *shardedIndex) DocCount() (uint64, error) {
+	st, err := x.group.openStats()
+	if err != nil {
+		return 0, err
+	}
+	return st.docCount, st.Close()
+}
+
+func (x *shardedIndex) Search(req *bleve.SearchRequest) (*bleve.SearchResult, error) {
+	return x.SearchInContext(context.Background(), req)
+}
+
+// SearchInContext searches all shards, reading the group's document and
+// term counts once for all of them.
+func (x *shardedIndex) SearchInContext(ctx context.Context, req *bleve.SearchRequest) (*bleve.SearchResult, error) {
+	st, err := x.group.openStats()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = st.Close() }()
+	return x.IndexAlias.SearchInContext(context.WithValue(ctx, groupStatsKey{}, st), req)
+}
+
+func (x *shardedIndex) Close() error {
+	first := x.IndexAlias.Close()
+	for _, shard := range x.shards {
+		if err := shard.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// newIndex creates an empty in-memory index, split into shards Bleve
+// indexes when shards > 1.
+func newIndex(shards int) (bleve.Index, error) {
+	if shards <= 1 {
+		return bleve.NewMemOnly(newIndexMapping())
+	}
+	return openIndex(shards, bleve.Config.DefaultMemKVStore, nil)
+}
+
+// openIndex opens an in-memory index split into shards Bleve indexes
+// (one plain index when shards <= 1) on KV stores of type kvstore.
+// storeConfig, if not nil, returns the store config of shard i.
+func openIndex(shards int, kvstore string, storeConfig func(i int) map[string]interface{}) (bleve.Index, error) {
+	config := func(i int) map[string]interface{} {
+		if storeConfig == nil {
+			return map[string]interface{}{}
+		}
+		return storeConfig(i)
+	}
+	if shards <= 1 {
+		return bleve.NewUsing("", newIndexMapping(), upsidedown.Name, kvstore, config(0))
+	}
+	if err := registerShardIndex(); err != nil {
+		return nil, err
+	}
+	group := &shardGroup{}
+	x := &shardedIndex{IndexAlias: bleve.NewIndexAlias(), group: group}
+	for i := range shards {
+		cfg := config(i)
+		cfg[shardGroupKey] = group
+		shard, err := bleve.NewUsing("", newIndexMapping(), shardIndexType, kvstore, cfg)
+		if err != nil {
+			return nil, closeOnError(x, err)
+		}
+		shard.SetName(fmt.Sprintf("shard-%d", i))
+		x.shards = append(x.shards, shard)
+	}
+	x.Add(x.shards...)
+	return x, nil
+}
+
+// indexShards returns the Bleve indexes of an index made by openIndex.
+func indexShards(idx bleve.Index) []bleve.Index {
+	if x, ok := idx.(*shardedIndex); ok {
+		return x.shards
+	}
+	return []bleve.Index{idx}
+}
+
+// shardOf returns the shard of the doc with id among n shards.
+func shardOf(id string, n int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(id))
+	return int(h.Sum32() % uint32(n))
+}
+
+// indexBatch is a batch of writes to an index made by newIndex, split
+// by shard.
+type indexBatch struct {
+	shards  []bleve.Index
+	batches []*bleve.Batch
+}
+
+func newIndexBatch(idx bleve.Index) *indexBatch {
+	shards := indexShards(idx)
+	b := &indexBatch{shards: shards, batches: make([]*bleve.Batch, len(shards))}
+	for i, shard := range shards {
+		b.batches[i] = shard.NewBatch()
+	}
+	return b
+}
+
+func (b *indexBatch) Index(id string, data interface{}) error {
+	return b.batches[shardOf(id, len(b.batches))].Index(id, data)
+}
+
+func (b *indexBatch) Delete(id string) {
+	b.batches[shardOf(id, len(b.batches))].Delete(id)
+}
+
+// apply writes the batch, indexing shards in parallel.
+func (b *indexBatch) apply() error {
+	return forEachShard(len(b.shards), func(i int) error {
+		return b.shards[i].Batch(b.batches[i])
+	})
+}
+
+// buildIndex indexes docs into an empty index made by newIndex. Each
+// shard maps its docs to data, analyzes and writes them on its own
+// goroutine.
+func buildIndex(idx bleve.Index, docs []toolindex.SearchDoc, data func(toolindex.SearchDoc) interface{}) error {
+	shards := indexShards(idx)
+	parts := make([][]toolindex.SearchDoc, len(shards))
+	for _, doc := range docs {
+		i := shardOf(doc.ID, len(shards))
+		parts[i] = append(parts[i], doc)
+	}
+	return forEachShard(len(shards), func(i int) error {
+		batch := shards[i].NewBatch()
+		for _, doc := range parts[i] {
+			if err := batch.Index(doc.ID, data(doc)); err != nil {
+				return err
+			}
+		}
+		return shards[i].Batch(batch)
+	})
+}
+
+// forEachShard runs fn for each of n shards, on separate goroutines when
+// n > 1.
+func forEachShard(n int, fn func(i int) error) error {
+	if n == 1 {
+		return fn(0)
+	}
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = fn(i)
+		}()
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("shard %d: %w", i, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package toolsearch
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// TestShards_MatchUnsharded checks that sharded scores, explanations and
+// order are identical to a single index, including with a visibility
+// filter and after a snapshot round trip.
+func TestShards_MatchUnsharded(t *testing.T) {
+	docs, queries := loadGoldenCorpus(t)
+	single := NewBM25Searcher(BM25Config{})
+	defer func() { _ = single.Close() }()
+	sharded := NewBM25Searcher(BM25Config{Shards: 4})
+	defer func() { _ = sharded.Close() }()
+
+	vis := &Visibility{ExcludeNamespaces: []string{docs[0].Summary.Namespace}}
+	compare := func(t *testing.T, s *BM25Searcher) {
+		t.Helper()
+		for _, opts := range []SearchOptions{{Explain: true}, {Visibility: vis}} {
+			for _, q := range queries {
+				want, err := single.SearchWithOptions(q, 10, docs, opts)
+				if err != nil {
+					t.Fatalf("unsharded search %q: %v", q, err)
+				}
+				got, err := s.SearchWithOptions(q, 10, docs, opts)
+				if err != nil {
+					t.Fatalf("sharded search %q: %v", q, err)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("query %q (%+v):\n got %+v\nwant %+v", q, opts, got.Hits, want.Hits)
+				}
+			}
+		}
+	}
+	compare(t, sharded)
+
+	var buf bytes.Buffer
+	if err := sharded.Snapshot(&buf); err != nil {
+		t.Fatalf("Snapshot error: %v", err)
+	}
+	for _, tc := range []struct {
+		shards, builds int
+	}{
+		{shards: 4, builds: 0}, // loads the archived rows
+		{shards: 3, builds: 1}, // re-indexes the archived docs
+	} {
+		restored := NewBM25Searcher(BM25Config{Shards: tc.shards})
+		defer func() { _ = restored.Close() }()
+		if err := restored.Restore(bytes.NewReader(buf.Bytes())); err != nil {
+			t.Fatalf("Restore into %d shards: %v", tc.shards, err)
+		}
+		compare(t, restored)
+		if restored.IndexBuildCount() != tc.builds {
+			t.Errorf("%d shards: IndexBuildCount = %d, want %d", tc.shards, restored.IndexBuildCount(), tc.builds)
+		}
+	}
+}
+
+func TestShards_LiveUpdates(t *testing.T) {
+	live, idx := newLiveIndex(t, BM25Config{Shards: 4})
+	register(t, idx,
+		liveTool("status", "git", "Show the working tree status"),
+		liveTool("log", "git", "Show commit logs"),
+		liveTool("ps", "docker", "List containers"),
+	)
+	live.Follow(IndexSource(idx))
+	searchIDs(t, idx, "show")
+
+	register(t, idx, liveTool("diff", "git", "Show changes between commits"))
+	if live.UpdateCount() == 0 {
+		t.Fatalf("UpdateCount = 0, want the update applied to the sharded index")
+	}
+	got := searchIDs(t, idx, "commits")
+
+	ref, refIdx := newLiveIndex(t, BM25Config{})
+	register(t, refIdx,
+		liveTool("status", "git", "Show the working tree status"),
+		liveTool("log", "git", "Show commit logs"),
+		liveTool("ps", "docker", "List containers"),
+		liveTool("diff", "git", "Show changes between commits"),
+	)
+	ref.Follow(IndexSource(refIdx))
+	if want := searchIDs(t, refIdx, "commits"); !reflect.DeepEqual(got, want) {
+		t.Errorf("sharded live results = %v, want %v", got, want)
+	}
+}
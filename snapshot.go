@@ -0,0 +1,371 @@
+package toolsearch
+
+import (
+	"bytes"
+	"cmp"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/index/upsidedown/store/gtreap"
+	"github.com/blevesearch/bleve/v2/registry"
+	store "github.com/blevesearch/upsidedown_store_api"
+	"github.com/jonwraymond/toolindex"
+)
+
+// Snapshot archive layout:
+//
+//	magic    8 bytes  "tsearch\x00"
+//	version  2 bytes  big-endian format version
+//	checksum 32 bytes SHA-256 of the payload
+//	payload  gzip-compressed JSON snapshotPayload
+const (
+	snapshotMagic   = "tsearch\x00"
+	snapshotVersion = 2
+)
+
+// Errors returned by Snapshot and Restore.
+var (
+	ErrNoIndex              = errors.New("no index built")
+	ErrInvalidSnapshot      = errors.New("invalid snapshot")
+	ErrSnapshotVersion      = errors.New("unsupported snapshot version")
+	ErrSnapshotConfigChange = errors.New("snapshot config does not match searcher config")
+)
+
+type snapshotPayload struct {
+	Fingerprint string             `json:"fingerprint"`
+	Config      snapshotConfig     `json:"config"`
+	Docs        []snapshotDoc      `json:"docs"`
+	Truncations []TruncationReport `json:"truncations,omitempty"`
+	Shards      [][]snapshotRow    `json:"shards"` // KV rows of each shard's index
+}
+
+// snapshotConfig holds the settings that shape the indexed content.
+type snapshotConfig struct {
+	NameBoost      int    `json:"name_boost"`
+	NamespaceBoost int    `json:"namespace_boost"`
+	TagsBoost      int    `json:"tags_boost"`
+	MaxDocs        int    `json:"max_docs"`
+	MaxDocTextLen  int    `json:"max_doctext_len"`
+	DocTextMode    int    `json:"doctext_mode"`
+	Truncation     string `json:"truncation,omitempty"`
+}
+
+// snapshotDoc is one indexed source document.
+type snapshotDoc struct {
+	ID      string            `json:"id"`
+	DocText string            `json:"doctext"`
+	Summary toolindex.Summary `json:"summary"`
+}
+
+// snapshotRow is a key-value row of Bleve's upside_down index.
+type snapshotRow struct {
+	Key   []byte `json:"k"`
+	Value []byte `json:"v"`
+}
+
+func snapshotConfigOf(cfg BM25Config) snapshotConfig {
+	return snapshotConfig{
+		NameBoost:      cfg.NameBoost,
+		NamespaceBoost: cfg.NamespaceBoost,
+		TagsBoost:      cfg.TagsBoost,
+		MaxDocs:        cfg.MaxDocs,
+		MaxDocTextLen:  cfg.MaxDocTextLen,
+		DocTextMode:    int(cfg.DocTextMode),
+		Truncation:     truncationName(cfg.Truncation),
+	}
+}
+
+// truncationName identifies a truncation policy in a snapshot config.
+// Policies built from functions are identified by kind only.
+func truncationName(p TruncationPolicy) string {
+	switch p := p.(type) {
+	case nil, byID:
+		return ""
+	case roundRobin:
+		return "round_robin"
+	case *byPriority:
+		return "priority"
+	case *pinnedIDs:
+		ids := slices.Sorted(maps.Keys(p.ids))
+		return "pinned(" + strings.Join(ids, ",") + ")+" + cmp.Or(truncationName(p.rest), "id")
+	default:
+		return fmt.Sprintf("%T", p)
+	}
+}
+
+// Snapshot writes the current index to w as a versioned, checksummed
+// archive holding the index's rows, the indexed documents, the ranking
+// config, the truncation reports and the catalog fingerprint. It returns
+// ErrNoIndex if no index has been built.
+func (s *BM25Searcher) Snapshot(w io.Writer) error {
+	s.mu.RLock()
+	if s.index == nil || s.lastSorted == nil {
+		s.mu.RUnlock()
+		return ErrNoIndex
+	}
+	sorted, truncations := s.lastSorted, s.truncations
+	// Read the rows under the lock so that live updates and rebuilds
+	// cannot change or close the index meanwhile.
+	shards, err := dumpIndex(s.index)
+	s.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("read index: %w", err)
+	}
+
+	payload := snapshotPayload{
+		// Recompute rather than copy lastFingerprint, which holds a
+		// generation while a LiveSearcher maintains the index.
+		Fingerprint: computeFingerprint(sorted),
+		Config:      snapshotConfigOf(s.cfg),
+		Docs:        make([]snapshotDoc, len(sorted)),
+		Truncations: truncations,
+		Shards:      shards,
+	}
+	for i, doc := range sorted {
+		payload.Docs[i] = snapshotDoc{ID: doc.ID, DocText: doc.DocText, Summary: doc.Summary}
+	}
+
+	var body bytes.Buffer
+	zw := gzip.NewWriter(&body)
+	if err := json.NewEncoder(zw).Encode(payload); err != nil {
+		return fmt.Errorf("encode snapshot: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("compress snapshot: %w", err)
+	}
+
+	header := make([]byte, 0, len(snapshotMagic)+2+sha256.Size)
+	header = append(header, snapshotMagic...)
+	header = binary.BigEndian.AppendUint16(header, snapshotVersion)
+	sum := sha256.Sum256(body.Bytes())
+	header = append(header, sum[:]...)
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	_, err = w.Write(body.Bytes())
+	return err
+}
+
+// Restore replaces the index with one read from a Snapshot archive. The
+// archive's checksum and fingerprint are verified, and its config must
+// match the searcher's. A later search whose docs match the snapshot's
+// fingerprint uses the restored index without rebuilding, and Stats and
+// Truncations report the archived catalog.
+//
+// The index is loaded from the archived rows without analyzing or
+// indexing any document. An archive written with a different number of
+// Shards is re-indexed from its documents instead, which counts as an
+// index build.
+func (s *BM25Searcher) Restore(r io.Reader) error {
+	payload, err := readSnapshot(r)
+	if err != nil {
+		return err
+	}
+	if payload.Config != snapshotConfigOf(s.cfg) {
+		return fmt.Errorf("%w: snapshot %+v, searcher %+v", ErrSnapshotConfigChange, payload.Config, snapshotConfigOf(s.cfg))
+	}
+
+	docs := make([]toolindex.SearchDoc, len(payload.Docs))
+	for i, d := range payload.Docs {
+		if i > 0 && d.ID <= payload.Docs[i-1].ID {
+			return fmt.Errorf("%w: documents not sorted by unique ID at %q", ErrInvalidSnapshot, d.ID)
+		}
+		docs[i] = toolindex.SearchDoc{ID: d.ID, DocText: d.DocText, Summary: d.Summary}
+	}
+	if fp := computeFingerprint(docs); fp != payload.Fingerprint {
+		return fmt.Errorf("%w: fingerprint mismatch", ErrInvalidSnapshot)
+	}
+	if err := s.checkBudget(docs); err != nil {
+		return err
+	}
+
+	s.stopBackground(false)
+	if len(payload.Shards) != max(s.cfg.Shards, 1) {
+		// Rows are split by doc ID hash, so they only fit the same number
+		// of shards.
+		return s.rebuildIndex(docs, payload.Fingerprint, payload.Truncations)
+	}
+
+	start := time.Now()
+	index, err := restoreIndex(payload.Shards)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+	}
+	if n, err := index.DocCount(); err != nil || n != uint64(len(docs)) {
+		return closeOnError(index, fmt.Errorf("%w: index holds %d of %d documents", ErrInvalidSnapshot, n, len(docs)))
+	}
+	idToSummary := make(map[string]toolindex.Summary, len(docs))
+	for _, doc := range docs {
+		idToSummary[doc.ID] = doc.Summary
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.index != nil {
+		if cerr := s.index.Close(); cerr != nil {
+			return closeOnError(index, fmt.Errorf("close old index: %w", cerr))
+		}
+	}
+	s.index = index
+	s.idToSummary = idToSummary
+	s.lastFingerprint = payload.Fingerprint
+	s.lastInput, s.lastSorted, s.lastVersion = nil, docs, ""
+	s.live = false
+	s.buildDuration, s.stats, s.dups, s.size = time.Since(start), nil, nil, nil
+	s.truncations = payload.Truncations
+	if s.cache != nil {
+		s.cache.purge()
+	}
+	return nil
+}
+
+// Restored indexes open on a KV store preloaded with the archived rows,
+// which Bleve's upside_down index reads like a persisted index.
+const (
+	restoreStoreType = "toolsearch_restore"
+	restoreRowsKey   = "toolsearch_restore_rows" // store config key of the []snapshotRow
+)
+
+// registerRestoreStore registers the restore store type with Bleve on
+// first use.
+var registerRestoreStore = sync.OnceValue(func() error {
+	return registry.RegisterKVStore(restoreStoreType, newRestoreStore)
+})
+
+func newRestoreStore(mo store.MergeOperator, config map[string]interface{}) (store.KVStore, error) {
+	rows, _ := config[restoreRowsKey].([]snapshotRow)
+	kv, err := gtreap.New(mo, config)
+	if err != nil {
+		return nil, err
+	}
+	w, err := kv.Writer()
+	if err != nil {
+		return nil, err
+	}
+	batch := w.NewBatch()
+	for _, row := range rows {
+		batch.Set(row.Key, row.Value)
+	}
+	if err := w.ExecuteBatch(batch); err != nil {
+		_ = w.Close()
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return kv, nil
+}
+
+// restoreIndex opens an index with a shard per element of shards, each
+// holding the given rows.
+func restoreIndex(shards [][]snapshotRow) (bleve.Index, error) {
+	if err := registerRestoreStore(); err != nil {
+		return nil, err
+	}
+	return openIndex(len(shards), restoreStoreType, func(i int) map[string]interface{} {
+		return map[string]interface{}{restoreRowsKey: shards[i]}
+	})
+}
+
+// dumpIndex returns the KV rows of each shard of an index made by
+// newIndex or restoreIndex.
+func dumpIndex(idx bleve.Index) ([][]snapshotRow, error) {
+	var out [][]snapshotRow
+	for _, shard := range indexShards(idx) {
+		adv, err := shard.Advanced()
+		if err != nil {
+			return nil, err
+		}
+		kv, ok := adv.(interface{ Advanced() (store.KVStore, error) })
+		if !ok {
+			return nil, fmt.Errorf("index type %T has no KV store", adv)
+		}
+		st, err := kv.Advanced()
+		if err != nil {
+			return nil, err
+		}
+		rows, err := dumpStore(st)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, rows)
+	}
+	return out, nil
+}
+
+func dumpStore(st store.KVStore) (rows []snapshotRow, err error) {
+	r, err := st.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if cerr := r.Close(); err == nil {
+			err = cerr
+		}
+	}()
+	it := r.RangeIterator(nil, nil)
+	defer func() {
+		if cerr := it.Close(); err == nil {
+			err = cerr
+		}
+	}()
+	for k, v, ok := it.Current(); ok; k, v, ok = it.Current() {
+		rows = append(rows, snapshotRow{Key: bytes.Clone(k), Value: bytes.Clone(v)})
+		it.Next()
+	}
+	return rows, nil
+}
+
+// readSnapshot reads and verifies an archive written by Snapshot.
+func readSnapshot(r io.Reader) (snapshotPayload, error) {
+	var payload snapshotPayload
+
+	header := make([]byte, len(snapshotMagic)+2+sha256.Size)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return payload, fmt.Errorf("%w: read header: %v", ErrInvalidSnapshot, err)
+	}
+	if string(header[:len(snapshotMagic)]) != snapshotMagic {
+		return payload, fmt.Errorf("%w: bad magic", ErrInvalidSnapshot)
+	}
+	if v := binary.BigEndian.Uint16(header[len(snapshotMagic):]); v != snapshotVersion {
+		return payload, fmt.Errorf("%w: %d", ErrSnapshotVersion, v)
+	}
+
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return payload, fmt.Errorf("read snapshot: %w", err)
+	}
+	sum := sha256.Sum256(body)
+	if !bytes.Equal(sum[:], header[len(snapshotMagic)+2:]) {
+		return payload, fmt.Errorf("%w: checksum mismatch", ErrInvalidSnapshot)
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return payload, fmt.Errorf("%w: %v", ErrInvalidSnapshot, err)
+	}
+	dec := json.NewDecoder(zr)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&payload); err != nil {
+		return payload, fmt.Errorf("%w: decode: %v", ErrInvalidSnapshot, err)
+	}
+	return payload, nil
+}
+
+func closeOnError(index bleve.Index, err error) error {
+	if cerr := index.Close(); cerr != nil {
+		return fmt.Errorf("%w; close index: %v", err, cerr)
+	}
+	return err
+}
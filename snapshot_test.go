@@ -0,0 +1,181 @@
+package toolsearch
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/jonwraymond/toolindex"
+)
+
+func snapshotOf(t *testing.T, cfg BM25Config, docs []toolindex.SearchDoc) []byte {
+	t.Helper()
+	s := NewBM25Searcher(cfg)
+	defer func() { _ = s.Close() }()
+	if _, err := s.Search("tool", 1, docs); err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := s.Snapshot(&buf); err != nil {
+		t.Fatalf("Snapshot error: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestSnapshot_RestoreSkipsRebuild(t *testing.T) {
+	docs := makeTestDocs(20)
+	data := snapshotOf(t, BM25Config{}, docs)
+
+	s := NewBM25Searcher(BM25Config{})
+	defer func() { _ = s.Close() }()
+	if err := s.Restore(bytes.NewReader(data)); err != nil {
+		t.Fatalf("Restore error: %v", err)
+	}
+
+	got, err := s.SearchWithOptions("tool 7", 5, docs, SearchOptions{})
+	if err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	if s.IndexBuildCount() != 0 {
+		t.Errorf("IndexBuildCount = %d, want 0 after restoring a matching snapshot", s.IndexBuildCount())
+	}
+
+	fresh := NewBM25Searcher(BM25Config{})
+	defer func() { _ = fresh.Close() }()
+	want, err := fresh.SearchWithOptions("tool 7", 5, docs, SearchOptions{})
+	if err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	if !reflect.DeepEqual(got.Hits, want.Hits) {
+		t.Errorf("restored hits %v differ from a fresh build %v", got.Hits, want.Hits)
+	}
+
+	// Different docs still trigger a rebuild.
+	if _, err := s.Search("tool", 5, makeTestDocs(3)); err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	if s.IndexBuildCount() != 1 {
+		t.Errorf("IndexBuildCount = %d, want 1 after a catalog change", s.IndexBuildCount())
+	}
+}
+
+func TestSnapshot_NoIndex(t *testing.T) {
+	s := NewBM25Searcher(BM25Config{})
+	if err := s.Snapshot(&bytes.Buffer{}); !errors.Is(err, ErrNoIndex) {
+		t.Errorf("Snapshot error = %v, want ErrNoIndex", err)
+	}
+}
+
+func TestRestore_IntegrityChecks(t *testing.T) {
+	data := snapshotOf(t, BM25Config{}, makeTestDocs(5))
+
+	corrupt := func(i int) []byte {
+		out := bytes.Clone(data)
+		out[i] ^= 0xff
+		return out
+	}
+	cases := map[string]struct {
+		data []byte
+		want error
+	}{
+		"truncated header": {data[:10], ErrInvalidSnapshot},
+		"bad magic":        {corrupt(0), ErrInvalidSnapshot},
+		"future version":   {corrupt(9), ErrSnapshotVersion},
+		"bad checksum":     {corrupt(12), ErrInvalidSnapshot},
+		"corrupt payload":  {corrupt(len(data) - 1), ErrInvalidSnapshot},
+		"truncated body":   {data[:len(data)-4], ErrInvalidSnapshot},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			s := NewBM25Searcher(BM25Config{})
+			if err := s.Restore(bytes.NewReader(tc.data)); !errors.Is(err, tc.want) {
+				t.Errorf("Restore error = %v, want %v", err, tc.want)
+			}
+			if s.IndexBuildCount() != 0 || s.index != nil {
+				t.Error("failed restore must leave the searcher empty")
+			}
+		})
+	}
+}
+
+func TestRestore_ConfigMismatch(t *testing.T) {
+	data := snapshotOf(t, BM25Config{NameBoost: 5}, makeTestDocs(5))
+
+	s := NewBM25Searcher(BM25Config{})
+	if err := s.Restore(bytes.NewReader(data)); !errors.Is(err, ErrSnapshotConfigChange) {
+		t.Errorf("Restore error = %v, want ErrSnapshotConfigChange", err)
+	}
+}
+
+func TestSnapshot_LiveIndexRoundTrip(t *testing.T) {
+	live, idx := newLiveIndex(t, BM25Config{})
+	register(t, idx, liveTool("status", "git", "Show the working tree status"))
+	live.Follow(IndexSource(idx))
+	searchIDs(t, idx, "status")
+	register(t, idx, liveTool("diff", "git", "Show changes between commits"))
+
+	var buf bytes.Buffer
+	if err := live.Snapshot(&buf); err != nil {
+		t.Fatalf("Snapshot error: %v", err)
+	}
+
+	capture := &docCapture{}
+	ref := toolindex.NewInMemoryIndex(toolindex.IndexOptions{Searcher: capture})
+	register(t, ref,
+		liveTool("status", "git", "Show the working tree status"),
+		liveTool("diff", "git", "Show changes between commits"),
+	)
+	if _, err := ref.Search("", 1); err != nil {
+		t.Fatalf("Search error: %v", err)
+	}
+
+	s := NewBM25Searcher(BM25Config{})
+	defer func() { _ = s.Close() }()
+	if err := s.Restore(&buf); err != nil {
+		t.Fatalf("Restore error: %v", err)
+	}
+	results, err := s.Search("show", 10, capture.docs)
+	if err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	if len(results) != 2 || s.IndexBuildCount() != 0 {
+		t.Errorf("got %d results and %d builds, want 2 and 0", len(results), s.IndexBuildCount())
+	}
+}
+
+func TestSnapshot_KeepsTruncations(t *testing.T) {
+	cfg := BM25Config{MaxDocs: 3, Truncation: TruncateRoundRobin()}
+	docs := makeTestDocs(5)
+	src := NewBM25Searcher(cfg)
+	defer func() { _ = src.Close() }()
+	if _, err := src.Search("tool", 1, docs); err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := src.Snapshot(&buf); err != nil {
+		t.Fatalf("Snapshot error: %v", err)
+	}
+
+	if err := NewBM25Searcher(BM25Config{MaxDocs: 3}).Restore(bytes.NewReader(buf.Bytes())); !errors.Is(err, ErrSnapshotConfigChange) {
+		t.Errorf("Restore with another truncation policy: error = %v, want ErrSnapshotConfigChange", err)
+	}
+
+	s := NewBM25Searcher(cfg)
+	defer func() { _ = s.Close() }()
+	if err := s.Restore(&buf); err != nil {
+		t.Fatalf("Restore error: %v", err)
+	}
+	if got, want := s.Truncations(), src.Truncations(); len(want) == 0 || !reflect.DeepEqual(got, want) {
+		t.Errorf("Truncations = %+v, want %+v", got, want)
+	}
+	if got := s.Stats().TruncatedDocs; got != 2 {
+		t.Errorf("Stats().TruncatedDocs = %d, want 2", got)
+	}
+	if _, err := s.Search("tool", 1, docs); err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	if s.IndexBuildCount() != 0 {
+		t.Errorf("IndexBuildCount = %d, want the restored index used", s.IndexBuildCount())
+	}
+}
@@ -0,0 +1,67 @@
+package toolsearch
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/jonwraymond/toolindex"
+)
+
+// Suggestion kinds reported by Suggest.
+const (
+	SuggestionName      = "name"
+	SuggestionNamespace = "namespace"
+	SuggestionTag       = "tag"
+)
+
+// Suggestion is a completion for a query prefix.
+type Suggestion struct {
+	Text  string `json:"text"`
+	Kind  string `json:"kind"`
+	Count int    `json:"count"`
+}
+
+// Suggest returns tool names, namespaces and tags starting with prefix,
+// compared case-insensitively. Count is the number of documents carrying
+// the value. Suggestions are ordered by count DESC, then text ASC, then
+// kind ASC. An empty prefix or non-positive limit returns no suggestions.
+func Suggest(prefix string, limit int, docs []toolindex.SearchDoc) []Suggestion {
+	prefix = strings.ToLower(strings.TrimSpace(prefix))
+	if prefix == "" || limit <= 0 {
+		return []Suggestion{}
+	}
+
+	type key struct{ text, kind string }
+	counts := make(map[key]int)
+	add := func(value, kind string) {
+		value = strings.ToLower(value)
+		if value != "" && strings.HasPrefix(value, prefix) {
+			counts[key{value, kind}]++
+		}
+	}
+	for _, doc := range docs {
+		add(doc.Summary.Name, SuggestionName)
+		add(doc.Summary.Namespace, SuggestionNamespace)
+		for _, tag := range doc.Summary.Tags {
+			add(tag, SuggestionTag)
+		}
+	}
+
+	out := make([]Suggestion, 0, len(counts))
+	for k, n := range counts {
+		out = append(out, Suggestion{Text: k.text, Kind: k.kind, Count: n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		if out[i].Text != out[j].Text {
+			return out[i].Text < out[j].Text
+		}
+		return out[i].Kind < out[j].Kind
+	})
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
@@ -0,0 +1,41 @@
+package toolsearch
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/jonwraymond/toolindex"
+)
+
+func TestSuggest_PrefixAcrossFields(t *testing.T) {
+	docs := []toolindex.SearchDoc{
+		{ID: "git:commit", Summary: toolindex.Summary{ID: "git:commit", Name: "commit", Namespace: "git", Tags: []string{"vcs"}}},
+		{ID: "git:checkout", Summary: toolindex.Summary{ID: "git:checkout", Name: "checkout", Namespace: "git", Tags: []string{"vcs"}}},
+		{ID: "ci:check", Summary: toolindex.Summary{ID: "ci:check", Name: "check", Namespace: "ci", Tags: []string{"ci"}}},
+	}
+
+	got := Suggest("C", 10, docs)
+	want := []Suggestion{
+		{Text: "check", Kind: SuggestionName, Count: 1},
+		{Text: "checkout", Kind: SuggestionName, Count: 1},
+		{Text: "ci", Kind: SuggestionNamespace, Count: 1},
+		{Text: "ci", Kind: SuggestionTag, Count: 1},
+		{Text: "commit", Kind: SuggestionName, Count: 1},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Suggest(C) = %v, want %v", got, want)
+	}
+
+	got = Suggest("g", 10, docs)
+	want = []Suggestion{{Text: "git", Kind: SuggestionNamespace, Count: 2}}
+	if !slices.Equal(got, want) {
+		t.Errorf("Suggest(g) = %v, want %v", got, want)
+	}
+
+	if got := Suggest("c", 2, docs); len(got) != 2 {
+		t.Errorf("expected limit to cap suggestions, got %v", got)
+	}
+	if got := Suggest(" ", 10, docs); got == nil || len(got) != 0 {
+		t.Errorf("expected empty, non-nil suggestions for blank prefix, got %v", got)
+	}
+}
@@ -0,0 +1,247 @@
+package toolsearch
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+
+	"github.com/jonwraymond/toolindex"
+)
+
+// TenantConfig configures a TenantSearcher.
+type TenantConfig struct {
+	// Search configures every tenant's BM25Searcher. Its MaxIndexBytes
+	// bounds a single tenant.
+	Search BM25Config
+
+	// Global budgets across resident tenants. When a search leaves either
+	// exceeded, the least recently used tenants are evicted; the tenant
+	// just searched is never evicted. 0 = unlimited.
+	MaxTenants int
+	MaxBytes   int64 // estimated index bytes, as reported by Stats
+}
+
+// TenantStats reports TenantSearcher activity.
+type TenantStats struct {
+	Tenants     int   `json:"tenants"`      // resident tenant indexes
+	ApproxBytes int64 `json:"approx_bytes"` // estimated bytes of resident indexes
+	Created     int   `json:"created"`      // tenant searchers created, including after eviction
+	Evictions   int   `json:"evictions"`
+	Builds      int   `json:"builds"` // index builds across all tenants
+}
+
+// TenantSearcher keeps an isolated BM25 index per tenant ID. Tenants are
+// created on first search and evicted, least recently used first, to stay
+// within the global budgets; an evicted tenant is rebuilt on its next
+// search.
+type TenantSearcher struct {
+	cfg TenantConfig
+
+	mu      sync.Mutex
+	tenants map[string]*tenantEntry
+	lru     *list.List // *tenantEntry, most recently used first
+	bytes   int64
+	stats   TenantStats
+	closed  bool
+}
+
+type tenantEntry struct {
+	id      string
+	s       *BM25Searcher
+	elem    *list.Element
+	bytes   int64
+	builds  int  // builds already counted in stats
+	refs    int  // searches in progress
+	evicted bool // removed from the LRU; closed when refs drops to 0
+}
+
+// ErrClosed is returned by searches on a closed TenantSearcher.
+var ErrClosed = errors.New("searcher closed")
+
+// NewTenantSearcher creates a tenant searcher with the given config.
+func NewTenantSearcher(cfg TenantConfig) *TenantSearcher {
+	return &TenantSearcher{
+		cfg:     cfg,
+		tenants: make(map[string]*tenantEntry),
+		lru:     list.New(),
+	}
+}
+
+// Search performs a BM25-ranked search over docs in tenant's index.
+func (t *TenantSearcher) Search(tenant, query string, limit int, docs []toolindex.SearchDoc) ([]toolindex.Summary, error) {
+	result, err := t.SearchWithOptions(tenant, query, limit, docs, SearchOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return result.Summaries(), nil
+}
+
+// SearchWithOptions performs a scored search over docs in tenant's index.
+func (t *TenantSearcher) SearchWithOptions(tenant, query string, limit int, docs []toolindex.SearchDoc, opts SearchOptions) (SearchResult, error) {
+	e, err := t.acquire(tenant)
+	if err != nil {
+		return SearchResult{}, err
+	}
+	result, err := e.s.SearchWithOptions(query, limit, docs, opts)
+	t.release(e)
+	return result, err
+}
+
+// For returns a toolindex.Searcher bound to tenant, for use as the
+// searcher of that tenant's toolindex index.
+func (t *TenantSearcher) For(tenant string) *TenantView {
+	return &TenantView{t: t, tenant: tenant}
+}
+
+// Evict drops tenant's index. Its next search rebuilds it.
+func (t *TenantSearcher) Evict(tenant string) error {
+	t.mu.Lock()
+	var closing []*BM25Searcher
+	if e, ok := t.tenants[tenant]; ok {
+		closing = t.evictLocked(e)
+		t.stats.Evictions++
+	}
+	t.mu.Unlock()
+	return closeAll(closing)
+}
+
+// Stats returns tenant counts, resident bytes and activity counters.
+func (t *TenantSearcher) Stats() TenantStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	st := t.stats
+	st.Tenants = len(t.tenants)
+	st.ApproxBytes = t.bytes
+	return st
+}
+
+// Close releases every tenant's index. Searches after Close fail with
+// ErrClosed.
+func (t *TenantSearcher) Close() error {
+	t.mu.Lock()
+	t.closed = true
+	var closing []*BM25Searcher
+	for _, e := range t.tenants {
+		closing = append(closing, t.evictLocked(e)...)
+	}
+	t.mu.Unlock()
+	return closeAll(closing)
+}
+
+// acquire returns tenant's entry, creating it if needed, and pins it
+// until release.
+func (t *TenantSearcher) acquire(tenant string) (*tenantEntry, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return nil, ErrClosed
+	}
+	e, ok := t.tenants[tenant]
+	if !ok {
+		e = &tenantEntry{id: tenant, s: NewBM25Searcher(t.cfg.Search)}
+		e.elem = t.lru.PushFront(e)
+		t.tenants[tenant] = e
+		t.stats.Created++
+	} else {
+		t.lru.MoveToFront(e.elem)
+	}
+	e.refs++
+	return e, nil
+}
+
+// release unpins e, records its size and enforces the budgets.
+func (t *TenantSearcher) release(e *tenantEntry) {
+	// Stats is cached per index, so this costs one catalog analysis
+	// after each build.
+	size := e.s.Stats().ApproxBytes
+	builds := e.s.IndexBuildCount()
+
+	t.mu.Lock()
+	e.refs--
+	if builds > e.builds {
+		t.stats.Builds += builds - e.builds
+		e.builds = builds
+	}
+	var closing []*BM25Searcher
+	if e.evicted {
+		if e.refs == 0 {
+			closing = append(closing, e.s)
+		}
+	} else {
+		t.bytes += size - e.bytes
+		e.bytes = size
+		closing = t.enforceLocked(e)
+	}
+	t.mu.Unlock()
+	// Like Close errors, failures to release an evicted index are
+	// operational warnings; the index is unreachable either way.
+	_ = closeAll(closing)
+}
+
+// enforceLocked evicts least recently used tenants other than keep until
+// the budgets hold. It returns searchers to close outside the lock.
+func (t *TenantSearcher) enforceLocked(keep *tenantEntry) []*BM25Searcher {
+	var closing []*BM25Searcher
+	over := func() bool {
+		return (t.cfg.MaxTenants > 0 && len(t.tenants) > t.cfg.MaxTenants) ||
+			(t.cfg.MaxBytes > 0 && t.bytes > t.cfg.MaxBytes)
+	}
+	for el := t.lru.Back(); el != nil && over(); {
+		e := el.Value.(*tenantEntry)
+		el = el.Prev()
+		if e != keep {
+			closing = append(closing, t.evictLocked(e)...)
+			t.stats.Evictions++
+		}
+	}
+	return closing
+}
+
+// evictLocked removes e from the resident set. It returns e's searcher if
+// it can be closed now; otherwise the last search in progress closes it.
+func (t *TenantSearcher) evictLocked(e *tenantEntry) []*BM25Searcher {
+	t.lru.Remove(e.elem)
+	delete(t.tenants, e.id)
+	t.bytes -= e.bytes
+	e.evicted = true
+	if e.refs > 0 {
+		return nil
+	}
+	return []*BM25Searcher{e.s}
+}
+
+func closeAll(searchers []*BM25Searcher) error {
+	var first error
+	for _, s := range searchers {
+		if err := s.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// TenantView is a TenantSearcher bound to one tenant. It implements
+// toolindex.Searcher.
+type TenantView struct {
+	t      *TenantSearcher
+	tenant string
+}
+
+// Ensure interface compliance at compile time.
+var _ toolindex.Searcher = (*TenantView)(nil)
+var _ toolindex.DeterministicSearcher = (*TenantView)(nil)
+
+// Deterministic reports whether this searcher returns stable ordering.
+func (v *TenantView) Deterministic() bool {
+	return true
+}
+
+// Search performs a BM25-ranked search in the tenant's index.
+func (v *TenantView) Search(query string, limit int, docs []toolindex.SearchDoc) ([]toolindex.Summary, error) {
+	return v.t.Search(v.tenant, query, limit, docs)
+}
+
+// SearchWithOptions performs a scored search in the tenant's index.
+func (v *TenantView) SearchWithOptions(query string, limit int, docs []toolindex.SearchDoc, opts SearchOptions) (SearchResult, error) {
+	return v.t.SearchWithOptions(v.tenant, query, limit, docs, opts)
+}
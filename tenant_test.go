@@ -0,0 +1,162 @@
+package toolsearch
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/jonwraymond/toolindex"
+)
+
+func tenantCatalog(tenant string, n int) []toolindex.SearchDoc {
+	docs := make([]toolindex.SearchDoc, n)
+	for i := range docs {
+		id := fmt.Sprintf("%s:tool_%d", tenant, i)
+		docs[i] = toolindex.SearchDoc{
+			ID:      id,
+			DocText: tenant + " shared tool",
+			Summary: toolindex.Summary{ID: id, Name: fmt.Sprintf("tool_%d", i), Namespace: tenant},
+		}
+	}
+	return docs
+}
+
+func tenantSearch(t *testing.T, ts *TenantSearcher, tenant string) []toolindex.Summary {
+	t.Helper()
+	results, err := ts.Search(tenant, "shared", 100, tenantCatalog(tenant, 5))
+	if err != nil {
+		t.Fatalf("Search(%s) error: %v", tenant, err)
+	}
+	return results
+}
+
+func TestTenantSearcher_Isolation(t *testing.T) {
+	ts := NewTenantSearcher(TenantConfig{})
+	defer func() { _ = ts.Close() }()
+
+	for _, tenant := range []string{"a", "b", "a", "b"} {
+		for _, r := range tenantSearch(t, ts, tenant) {
+			if r.Namespace != tenant {
+				t.Fatalf("tenant %s saw %s", tenant, r.ID)
+			}
+		}
+	}
+	st := ts.Stats()
+	if st.Tenants != 2 || st.Created != 2 || st.Builds != 2 || st.Evictions != 0 || st.ApproxBytes <= 0 {
+		t.Errorf("Stats = %+v, want 2 tenants built once each", st)
+	}
+}
+
+func TestTenantSearcher_MaxTenantsEvictsLRU(t *testing.T) {
+	ts := NewTenantSearcher(TenantConfig{MaxTenants: 2})
+	defer func() { _ = ts.Close() }()
+
+	tenantSearch(t, ts, "a")
+	tenantSearch(t, ts, "b")
+	tenantSearch(t, ts, "a") // b is now least recently used
+	tenantSearch(t, ts, "c")
+
+	st := ts.Stats()
+	if st.Tenants != 2 || st.Evictions != 1 {
+		t.Fatalf("Stats = %+v, want 2 resident tenants after 1 eviction", st)
+	}
+	tenantSearch(t, ts, "a")
+	if got := ts.Stats(); got.Builds != st.Builds || got.Evictions != 1 {
+		t.Errorf("searching resident tenant a changed stats: %+v", got)
+	}
+
+	tenantSearch(t, ts, "b")
+	if got := ts.Stats(); got.Created != 4 || got.Builds != 4 || got.Evictions != 2 {
+		t.Errorf("Stats = %+v, want b recreated and rebuilt", got)
+	}
+}
+
+func TestTenantSearcher_MaxBytes(t *testing.T) {
+	probe := NewTenantSearcher(TenantConfig{})
+	tenantSearch(t, probe, "a")
+	one := probe.Stats().ApproxBytes
+	_ = probe.Close()
+
+	ts := NewTenantSearcher(TenantConfig{MaxBytes: one + one/2})
+	defer func() { _ = ts.Close() }()
+	tenantSearch(t, ts, "a")
+	tenantSearch(t, ts, "b")
+	if st := ts.Stats(); st.Tenants != 1 || st.Evictions != 1 || st.ApproxBytes > ts.cfg.MaxBytes {
+		t.Errorf("Stats = %+v, want one tenant within %d bytes", st, ts.cfg.MaxBytes)
+	}
+
+	// A tenant larger than the budget on its own stays resident.
+	small := NewTenantSearcher(TenantConfig{MaxBytes: 1})
+	defer func() { _ = small.Close() }()
+	if got := tenantSearch(t, small, "a"); len(got) != 5 {
+		t.Errorf("got %d results from an oversized tenant, want 5", len(got))
+	}
+	if st := small.Stats(); st.Tenants != 1 {
+		t.Errorf("Stats = %+v, want the searched tenant kept", st)
+	}
+}
+
+func TestTenantSearcher_ViewAndEvict(t *testing.T) {
+	ts := NewTenantSearcher(TenantConfig{})
+	defer func() { _ = ts.Close() }()
+
+	idx := toolindex.NewInMemoryIndex(toolindex.IndexOptions{Searcher: ts.For("git")})
+	register(t, idx, liveTool("status", "git", "Show the working tree status"))
+	if got := searchIDs(t, idx, "status"); len(got) != 1 {
+		t.Fatalf("search through view = %v", got)
+	}
+
+	if err := ts.Evict("git"); err != nil {
+		t.Fatalf("Evict error: %v", err)
+	}
+	if st := ts.Stats(); st.Tenants != 0 || st.ApproxBytes != 0 || st.Evictions != 1 {
+		t.Errorf("Stats after Evict = %+v", st)
+	}
+	if got := searchIDs(t, idx, "status"); len(got) != 1 {
+		t.Errorf("search after eviction = %v", got)
+	}
+	if st := ts.Stats(); st.Builds != 2 {
+		t.Errorf("Builds = %d, want a rebuild after eviction", st.Builds)
+	}
+}
+
+func TestTenantSearcher_ConcurrentEviction(t *testing.T) {
+	ts := NewTenantSearcher(TenantConfig{MaxTenants: 2})
+	defer func() { _ = ts.Close() }()
+
+	var wg sync.WaitGroup
+	for g := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 30 {
+				tenant := fmt.Sprintf("t%d", (g+i)%5)
+				results, err := ts.Search(tenant, "shared", 10, tenantCatalog(tenant, 3))
+				if err != nil {
+					t.Errorf("Search error: %v", err)
+					return
+				}
+				if len(results) != 3 {
+					t.Errorf("tenant %s: got %d results, want 3", tenant, len(results))
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if st := ts.Stats(); st.Tenants > 2 || st.Evictions == 0 {
+		t.Errorf("Stats = %+v, want at most 2 resident tenants", st)
+	}
+}
+
+func TestTenantSearcher_Closed(t *testing.T) {
+	ts := NewTenantSearcher(TenantConfig{})
+	tenantSearch(t, ts, "a")
+	if err := ts.Close(); err != nil {
+		t.Fatalf("Close error: %v", err)
+	}
+	if _, err := ts.Search("a", "shared", 10, nil); !errors.Is(err, ErrClosed) {
+		t.Errorf("Search after Close error = %v, want ErrClosed", err)
+	}
+}
@@ -0,0 +1,132 @@
+package toolsearch
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/jonwraymond/toolindex"
+)
+
+// Tokenizer counts the tokens a model spends on text. Implementations
+// wrapping a model's real tokenizer give exact budgets; ApproxTokenizer
+// needs no vocabulary.
+type Tokenizer interface {
+	CountTokens(text string) int
+}
+
+// ApproxTokenizer estimates token counts the way BPE tokenizers split
+// English and JSON: a token per four bytes of each run of letters and
+// digits, rounded up, and a token per other character except spaces. It
+// tends to overestimate slightly, so budgets are rarely exceeded.
+type ApproxTokenizer struct{}
+
+// CountTokens implements Tokenizer.
+func (ApproxTokenizer) CountTokens(text string) int {
+	n, word := 0, 0 // word is the byte length of the current run
+	for _, r := range text {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word += utf8.RuneLen(r)
+			continue
+		case !unicode.IsSpace(r):
+			n++
+		}
+		n += (word + 3) / 4
+		word = 0
+	}
+	return n + (word+3)/4
+}
+
+// TokenBudget packs search results into a fixed number of tokens instead
+// of a fixed count. Hits are taken in rank order while their JSON
+// encoding, summary and alternates, fits in MaxTokens; limit still caps
+// the count, so pass a generous one. Packing stops at the first hit that
+// does not fit, keeping the results a prefix of the ranking.
+type TokenBudget struct {
+	MaxTokens int
+	Tokenizer Tokenizer // nil = ApproxTokenizer
+
+	// TrimDescriptions fits the first hit that does not fit whole by
+	// cutting its ShortDescription at a word boundary, marked with "…",
+	// or removing it. The hit is dropped if it does not fit even then.
+	TrimDescriptions bool
+}
+
+// ellipsis marks a ShortDescription trimmed to fit a TokenBudget.
+const ellipsis = "…"
+
+// Pack returns result with the hits that fit b, setting TokensUsed and
+// Dropped, for packing results of searchers that do not support
+// SearchOptions.Budget. It does not modify result's hits. A nil budget
+// returns result unchanged.
+func (b *TokenBudget) Pack(result SearchResult) SearchResult {
+	if b == nil {
+		return result
+	}
+	tok := b.Tokenizer
+	if tok == nil {
+		tok = ApproxTokenizer{}
+	}
+	hits := make([]Hit, 0, len(result.Hits))
+	used := 0
+	for _, hit := range result.Hits {
+		cost := hitTokens(tok, hit)
+		if used+cost > b.MaxTokens {
+			if b.TrimDescriptions {
+				if hit, cost = trimHit(tok, hit, b.MaxTokens-used); used+cost <= b.MaxTokens {
+					hits = append(hits, hit)
+					used += cost
+				}
+			}
+			break
+		}
+		hits = append(hits, hit)
+		used += cost
+	}
+	result.Dropped = len(result.Hits) - len(hits)
+	result.Hits, result.TokensUsed = hits, used
+	return result
+}
+
+// hitTokens counts the tokens of the summaries a hit returns, as JSON.
+func hitTokens(tok Tokenizer, hit Hit) int {
+	n := summaryTokens(tok, hit.Summary)
+	for _, alt := range hit.Alternates {
+		n += summaryTokens(tok, alt)
+	}
+	return n
+}
+
+func summaryTokens(tok Tokenizer, summary toolindex.Summary) int {
+	data, _ := json.Marshal(summary) // a Summary always encodes
+	return tok.CountTokens(string(data))
+}
+
+// trimHit returns hit with the longest word prefix of its
+// ShortDescription that fits in budget tokens, and its cost. If none
+// does, the description is removed.
+func trimHit(tok Tokenizer, hit Hit, budget int) (Hit, int) {
+	words := strings.Fields(hit.Summary.ShortDescription)
+	cost := func(n int) (Hit, int) {
+		h := hit
+		h.Summary.ShortDescription = ""
+		if n > 0 {
+			h.Summary.ShortDescription = strings.Join(words[:n], " ") + ellipsis
+		}
+		return h, hitTokens(tok, h)
+	}
+	// Binary search for the most words that fit; the whole description
+	// is known not to.
+	lo, hi := 0, len(words)-1
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if _, c := cost(mid); c <= budget {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return cost(lo)
+}
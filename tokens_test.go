@@ -0,0 +1,139 @@
+package toolsearch_test
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jonwraymond/toolindex"
+	"github.com/jonwraymond/toolsearch"
+)
+
+func TestApproxTokenizer(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"List containers", 4},
+		{`{"id":"a"}`, 9},
+		{"kubectl_get", 4},
+		{"  spaced   out  ", 3},
+	}
+	for _, tt := range tests {
+		if got := (toolsearch.ApproxTokenizer{}).CountTokens(tt.text); got != tt.want {
+			t.Errorf("CountTokens(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+// byteTokenizer counts a token per byte.
+type byteTokenizer struct{}
+
+func (byteTokenizer) CountTokens(text string) int { return len(text) }
+
+// hitTokens returns the tokens that hit costs in a budget.
+func hitTokens(tok toolsearch.Tokenizer, hit toolsearch.Hit) int {
+	budget := &toolsearch.TokenBudget{MaxTokens: math.MaxInt, Tokenizer: tok}
+	return budget.Pack(toolsearch.SearchResult{Hits: []toolsearch.Hit{hit}}).TokensUsed
+}
+
+func TestSearch_TokenBudget(t *testing.T) {
+	s := toolsearch.NewBM25Searcher(toolsearch.BM25Config{CacheSize: 8})
+	defer func() { _ = s.Close() }()
+	docs := exampleCatalog(t).Docs
+
+	full, err := s.SearchWithOptions("devops", 10, docs, toolsearch.SearchOptions{})
+	if err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	if len(full.Hits) != 4 || full.TokensUsed != 0 || full.Dropped != 0 {
+		t.Fatalf("unbudgeted result = %+v", full)
+	}
+	tok := toolsearch.ApproxTokenizer{}
+	two := hitTokens(tok, full.Hits[0]) + hitTokens(tok, full.Hits[1])
+
+	// A budget one token short of the third hit's description fits two
+	// hits, or three when the description is trimmed.
+	slack := hitTokens(tok, full.Hits[2]) - 1
+	result, err := s.SearchWithOptions("devops", 10, docs, toolsearch.SearchOptions{Budget: &toolsearch.TokenBudget{MaxTokens: two + slack}})
+	if err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	if !reflect.DeepEqual(result.Hits, full.Hits[:2]) || result.TokensUsed != two || result.Dropped != 2 {
+		t.Errorf("packed result = %v, %d tokens, %d dropped", hitIDs(result.Hits), result.TokensUsed, result.Dropped)
+	}
+
+	budget := &toolsearch.TokenBudget{MaxTokens: two + slack, TrimDescriptions: true}
+	result, err = s.SearchWithOptions("devops", 10, docs, toolsearch.SearchOptions{Budget: budget})
+	if err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	if got, want := hitIDs(result.Hits), hitIDs(full.Hits[:3]); !reflect.DeepEqual(got, want) {
+		t.Fatalf("trimmed hits = %v, want %v", got, want)
+	}
+	trimmed := result.Hits[2].Summary.ShortDescription
+	if desc := full.Hits[2].Summary.ShortDescription; !strings.HasSuffix(trimmed, "…") || !strings.HasPrefix(desc, strings.TrimSuffix(trimmed, "…")) {
+		t.Errorf("trimmed description %q of %q", trimmed, desc)
+	}
+	if result.TokensUsed > budget.MaxTokens || result.Dropped != 1 {
+		t.Errorf("trimmed result: %d of %d tokens, %d dropped", result.TokensUsed, budget.MaxTokens, result.Dropped)
+	}
+
+	// Packing leaves cached results whole.
+	again, err := s.SearchWithOptions("devops", 10, docs, toolsearch.SearchOptions{})
+	if err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	if !reflect.DeepEqual(again, full) {
+		t.Errorf("cached result after packing = %+v", again)
+	}
+
+	// limit still caps the hits, and the tokenizer is pluggable.
+	result, err = s.SearchWithOptions("devops", 3, docs, toolsearch.SearchOptions{Budget: &toolsearch.TokenBudget{MaxTokens: 1 << 20, Tokenizer: byteTokenizer{}}})
+	if err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	want := 0
+	for _, hit := range full.Hits[:3] {
+		want += hitTokens(byteTokenizer{}, hit)
+	}
+	if len(result.Hits) != 3 || result.TokensUsed != want || result.Dropped != 0 {
+		t.Errorf("byte-budgeted result: %d hits, %d tokens (want %d), %d dropped", len(result.Hits), result.TokensUsed, want, result.Dropped)
+	}
+
+	result, err = s.SearchWithOptions("devops", 10, docs, toolsearch.SearchOptions{Budget: &toolsearch.TokenBudget{MaxTokens: 1, TrimDescriptions: true}})
+	if err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	if len(result.Hits) != 0 || result.TokensUsed != 0 || result.Dropped != 4 {
+		t.Errorf("tiny budget result = %+v", result)
+	}
+}
+
+func TestTokenBudget_TrimsOnlyFirstMisfit(t *testing.T) {
+	long := toolsearch.Hit{Summary: toolindex.Summary{ID: "a", ShortDescription: "alpha " + strings.Repeat("x", 50)}}
+	small := toolsearch.Hit{Summary: toolindex.Summary{ID: "b"}}
+	trimmed := long
+	trimmed.Summary.ShortDescription = "alpha…"
+	tok := byteTokenizer{}
+
+	// The trimmed first hit leaves room for the second, but packing stops
+	// after the first trim.
+	budget := &toolsearch.TokenBudget{MaxTokens: hitTokens(tok, trimmed) + hitTokens(tok, small), Tokenizer: tok, TrimDescriptions: true}
+	result := budget.Pack(toolsearch.SearchResult{Hits: []toolsearch.Hit{long, small}})
+	if !reflect.DeepEqual(result.Hits, []toolsearch.Hit{trimmed}) || result.Dropped != 1 {
+		t.Errorf("packed = %+v, %d dropped; want only the trimmed first hit", result.Hits, result.Dropped)
+	}
+
+	// A trim that fails stops packing too.
+	budget.MaxTokens = hitTokens(tok, small) - 1
+	if result := budget.Pack(toolsearch.SearchResult{Hits: []toolsearch.Hit{long, small}}); len(result.Hits) != 0 || result.Dropped != 2 {
+		t.Errorf("packed = %+v, %d dropped; want none", result.Hits, result.Dropped)
+	}
+
+	if result := (*toolsearch.TokenBudget)(nil).Pack(toolsearch.SearchResult{Hits: []toolsearch.Hit{long}}); len(result.Hits) != 1 || result.Dropped != 0 {
+		t.Errorf("nil budget packed %+v", result)
+	}
+}
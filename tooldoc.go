@@ -0,0 +1,43 @@
+package toolsearch
+
+import (
+	"strings"
+
+	"github.com/jonwraymond/toolindex"
+	"github.com/jonwraymond/toolmodel"
+)
+
+// SearchDocFromTool builds the search document toolindex derives for a
+// registered tool: normalized tags, lowercased DocText over name, namespace,
+// description and tags, and a summary with a capped short description.
+//
+// It lets callers that hold tool definitions but no toolindex.Index feed
+// a searcher the same documents the index would.
+func SearchDocFromTool(tool toolmodel.Tool) toolindex.SearchDoc {
+	tags := toolmodel.NormalizeTags(tool.Tags)
+
+	parts := []string{
+		strings.ToLower(tool.Name),
+		strings.ToLower(tool.Namespace),
+		strings.ToLower(tool.Description),
+	}
+	parts = append(parts, tags...)
+
+	shortDesc := tool.Description
+	if len(shortDesc) > toolindex.MaxShortDescriptionLen {
+		shortDesc = shortDesc[:toolindex.MaxShortDescriptionLen]
+	}
+
+	id := tool.ToolID()
+	return toolindex.SearchDoc{
+		ID:      id,
+		DocText: strings.Join(parts, " "),
+		Summary: toolindex.Summary{
+			ID:               id,
+			Name:             tool.Name,
+			Namespace:        tool.Namespace,
+			ShortDescription: shortDesc,
+			Tags:             tags,
+		},
+	}
+}
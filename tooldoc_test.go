@@ -0,0 +1,53 @@
+package toolsearch
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jonwraymond/toolindex"
+	"github.com/jonwraymond/toolmodel"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// docCapture records the docs toolindex passes to its searcher.
+type docCapture struct {
+	docs []toolindex.SearchDoc
+}
+
+func (c *docCapture) Search(_ string, _ int, docs []toolindex.SearchDoc) ([]toolindex.Summary, error) {
+	c.docs = docs
+	return nil, nil
+}
+
+func (c *docCapture) Deterministic() bool { return true }
+
+func TestSearchDocFromTool_MatchesToolindex(t *testing.T) {
+	tool := toolmodel.Tool{
+		Tool: mcp.Tool{
+			Name:        "create_issue",
+			Description: "Create a new issue. " + strings.Repeat("Long description text. ", 10),
+			InputSchema: map[string]any{"type": "object"},
+		},
+		Namespace: "GitHub",
+		Tags:      []string{"Issues", " bug tracking ", "issues"},
+	}
+
+	capture := &docCapture{}
+	idx := toolindex.NewInMemoryIndex(toolindex.IndexOptions{Searcher: capture})
+	backend := toolmodel.ToolBackend{Kind: toolmodel.BackendKindMCP, MCP: &toolmodel.MCPBackend{ServerName: "gh"}}
+	if err := idx.RegisterTool(tool, backend); err != nil {
+		t.Fatalf("RegisterTool error: %v", err)
+	}
+	if _, err := idx.Search("", 1); err != nil {
+		t.Fatalf("Search error: %v", err)
+	}
+	if len(capture.docs) != 1 {
+		t.Fatalf("captured %d docs, want 1", len(capture.docs))
+	}
+
+	got := SearchDocFromTool(tool)
+	if !reflect.DeepEqual(got, capture.docs[0]) {
+		t.Errorf("SearchDocFromTool mismatch\n got: %+v\nwant: %+v", got, capture.docs[0])
+	}
+}
@@ -0,0 +1,195 @@
+package toolsearch
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/jonwraymond/toolindex"
+)
+
+// TruncationPolicy chooses which docs survive when a catalog exceeds
+// MaxDocs, or MaxIndexBytes with BudgetTruncate.
+type TruncationPolicy interface {
+	// Select returns up to n of docs, most important first. docs is
+	// sorted by ID and must not be modified. The selection must depend
+	// only on docs, so that searches stay deterministic.
+	Select(docs []toolindex.SearchDoc, n int) []toolindex.SearchDoc
+}
+
+// Truncation reasons reported in TruncationReport.
+const (
+	TruncatedByMaxDocs       = "max_docs"
+	TruncatedByMaxIndexBytes = "max_index_bytes"
+)
+
+// TruncationReport describes docs dropped from a catalog.
+type TruncationReport struct {
+	Reason  string   `json:"reason"`  // TruncatedByMaxDocs or TruncatedByMaxIndexBytes
+	Total   int      `json:"total"`   // docs before this truncation
+	Kept    int      `json:"kept"`    // docs remaining
+	Dropped []string `json:"dropped"` // IDs of dropped docs, sorted
+}
+
+// TruncateByID keeps the docs with the lowest IDs. It is the default.
+func TruncateByID() TruncationPolicy {
+	return byID{}
+}
+
+type byID struct{}
+
+func (byID) Select(docs []toolindex.SearchDoc, n int) []toolindex.SearchDoc {
+	return docs[:min(n, len(docs))]
+}
+
+// TruncateRoundRobin takes one doc per namespace in turn, cycling through
+// namespaces in name order and each namespace's docs in ID order, so every
+// namespace keeps an even share.
+func TruncateRoundRobin() TruncationPolicy {
+	return roundRobin{}
+}
+
+type roundRobin struct{}
+
+func (roundRobin) Select(docs []toolindex.SearchDoc, n int) []toolindex.SearchDoc {
+	byNamespace := make(map[string][]toolindex.SearchDoc)
+	for _, doc := range docs {
+		ns := doc.Summary.Namespace
+		byNamespace[ns] = append(byNamespace[ns], doc)
+	}
+	namespaces := make([]string, 0, len(byNamespace))
+	for ns := range byNamespace {
+		namespaces = append(namespaces, ns)
+	}
+	slices.Sort(namespaces)
+
+	n = min(n, len(docs))
+	out := make([]toolindex.SearchDoc, 0, n)
+	for round := 0; len(out) < n; round++ {
+		for _, ns := range namespaces {
+			if group := byNamespace[ns]; round < len(group) && len(out) < n {
+				out = append(out, group[round])
+			}
+		}
+	}
+	return out
+}
+
+// TruncateByPriority keeps the docs with the highest priority, such as a
+// usage count from selection logs. Ties keep the lower ID.
+func TruncateByPriority(priority func(toolindex.SearchDoc) float64) TruncationPolicy {
+	return &byPriority{priority: priority}
+}
+
+type byPriority struct {
+	priority func(toolindex.SearchDoc) float64
+}
+
+func (p *byPriority) Select(docs []toolindex.SearchDoc, n int) []toolindex.SearchDoc {
+	type ranked struct {
+		doc      toolindex.SearchDoc
+		priority float64
+	}
+	all := make([]ranked, len(docs))
+	for i, doc := range docs {
+		all[i] = ranked{doc, p.priority(doc)}
+	}
+	// docs is in ID order, so a stable sort breaks ties by ID.
+	slices.SortStableFunc(all, func(a, b ranked) int {
+		return cmp.Compare(b.priority, a.priority)
+	})
+
+	out := make([]toolindex.SearchDoc, min(n, len(all)))
+	for i := range out {
+		out[i] = all[i].doc
+	}
+	return out
+}
+
+// TruncatePinned always keeps the docs whose IDs are in pinned, in ID
+// order, and fills the remaining slots with rest (TruncateByID if nil).
+func TruncatePinned(pinned []string, rest TruncationPolicy) TruncationPolicy {
+	if rest == nil {
+		rest = TruncateByID()
+	}
+	p := &pinnedIDs{ids: make(map[string]struct{}, len(pinned)), rest: rest}
+	for _, id := range pinned {
+		p.ids[id] = struct{}{}
+	}
+	return p
+}
+
+type pinnedIDs struct {
+	ids  map[string]struct{}
+	rest TruncationPolicy
+}
+
+func (p *pinnedIDs) Select(docs []toolindex.SearchDoc, n int) []toolindex.SearchDoc {
+	var kept, others []toolindex.SearchDoc
+	for _, doc := range docs {
+		if _, ok := p.ids[doc.ID]; ok {
+			kept = append(kept, doc)
+		} else {
+			others = append(others, doc)
+		}
+	}
+	if len(kept) >= n {
+		return kept[:n]
+	}
+	return append(kept, p.rest.Select(others, n-len(kept))...)
+}
+
+// truncate applies MaxDocs and MaxIndexBytes to docs sorted by ID. It
+// returns the docs to index, still sorted by ID, and a report for each
+// limit that dropped docs.
+func (s *BM25Searcher) truncate(sorted []toolindex.SearchDoc) ([]toolindex.SearchDoc, []TruncationReport, error) {
+	var reports []TruncationReport
+	if s.cfg.MaxDocs > 0 && len(sorted) > s.cfg.MaxDocs {
+		kept := sortDocsByID(s.prioritize(sorted, s.cfg.MaxDocs))
+		reports = append(reports, truncationReport(TruncatedByMaxDocs, sorted, kept))
+		sorted = kept
+	}
+	if s.cfg.MaxIndexBytes > 0 {
+		kept, err := s.fitBudget(sorted)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(kept) < len(sorted) {
+			reports = append(reports, truncationReport(TruncatedByMaxIndexBytes, sorted, kept))
+		}
+		sorted = kept
+	}
+	return sorted, reports, nil
+}
+
+// prioritize returns up to n of sorted, most important first.
+func (s *BM25Searcher) prioritize(sorted []toolindex.SearchDoc, n int) []toolindex.SearchDoc {
+	policy := s.cfg.Truncation
+	if policy == nil {
+		policy = TruncateByID()
+	}
+	kept := policy.Select(sorted, n)
+	return kept[:min(n, len(kept))]
+}
+
+func truncationReport(reason string, all, kept []toolindex.SearchDoc) TruncationReport {
+	keptIDs := make(map[string]struct{}, len(kept))
+	for _, doc := range kept {
+		keptIDs[doc.ID] = struct{}{}
+	}
+	dropped := make([]string, 0, len(all)-len(kept))
+	for _, doc := range all {
+		if _, ok := keptIDs[doc.ID]; !ok {
+			dropped = append(dropped, doc.ID)
+		}
+	}
+	return TruncationReport{Reason: reason, Total: len(all), Kept: len(kept), Dropped: dropped}
+}
+
+// Truncations reports the docs dropped from the catalog of the current
+// index, one report per limit that applied. It returns nil when the whole
+// catalog is indexed.
+func (s *BM25Searcher) Truncations() []TruncationReport {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return slices.Clone(s.truncations)
+}
@@ -0,0 +1,134 @@
+package toolsearch
+
+import (
+	"math/rand/v2"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/jonwraymond/toolindex"
+)
+
+func namespacedDocs() []toolindex.SearchDoc {
+	var docs []toolindex.SearchDoc
+	for _, ns := range []string{"aws", "git", "zz"} {
+		for _, name := range []string{"create", "delete", "list"} {
+			id := ns + ":" + name
+			docs = append(docs, toolindex.SearchDoc{
+				ID:      id,
+				DocText: name + " resources",
+				Summary: toolindex.Summary{ID: id, Name: name, Namespace: ns},
+			})
+		}
+	}
+	return docs
+}
+
+func selectedIDs(policy TruncationPolicy, n int) []string {
+	var ids []string
+	for _, doc := range policy.Select(namespacedDocs(), n) {
+		ids = append(ids, doc.ID)
+	}
+	return ids
+}
+
+func TestTruncationPolicies(t *testing.T) {
+	priority := map[string]float64{"zz:list": 9, "git:delete": 5, "aws:list": 5}
+	cases := map[string]struct {
+		policy TruncationPolicy
+		want   []string
+	}{
+		"by ID":       {TruncateByID(), []string{"aws:create", "aws:delete", "aws:list", "git:create"}},
+		"round robin": {TruncateRoundRobin(), []string{"aws:create", "git:create", "zz:create", "aws:delete"}},
+		"priority": {
+			TruncateByPriority(func(d toolindex.SearchDoc) float64 { return priority[d.ID] }),
+			[]string{"zz:list", "aws:list", "git:delete", "aws:create"},
+		},
+		"pinned": {
+			TruncatePinned([]string{"zz:delete", "git:list", "missing"}, TruncateRoundRobin()),
+			[]string{"git:list", "zz:delete", "aws:create", "git:create"},
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if got := selectedIDs(tc.policy, 4); !slices.Equal(got, tc.want) {
+				t.Errorf("Select = %v, want %v", got, tc.want)
+			}
+			if got := selectedIDs(tc.policy, 100); len(got) != 9 {
+				t.Errorf("Select with room for all kept %d docs, want 9", len(got))
+			}
+		})
+	}
+
+	if got := selectedIDs(TruncatePinned([]string{"zz:list", "aws:list"}, nil), 1); !slices.Equal(got, []string{"aws:list"}) {
+		t.Errorf("pinned overflow = %v, want the lowest pinned ID", got)
+	}
+}
+
+func TestSearch_MaxDocsTruncationPolicy(t *testing.T) {
+	s := NewBM25Searcher(BM25Config{MaxDocs: 3, Truncation: TruncateRoundRobin()})
+	defer func() { _ = s.Close() }()
+
+	docs := namespacedDocs()
+	rand.New(rand.NewPCG(1, 2)).Shuffle(len(docs), func(i, j int) { docs[i], docs[j] = docs[j], docs[i] })
+	results, err := s.Search("resources", 10, docs)
+	if err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	var ids []string
+	for _, r := range results {
+		ids = append(ids, r.ID)
+	}
+	if !slices.Equal(ids, []string{"aws:create", "git:create", "zz:create"}) {
+		t.Errorf("results = %v, want one create tool per namespace", ids)
+	}
+
+	want := []TruncationReport{{
+		Reason:  TruncatedByMaxDocs,
+		Total:   9,
+		Kept:    3,
+		Dropped: []string{"aws:delete", "aws:list", "git:delete", "git:list", "zz:delete", "zz:list"},
+	}}
+	if got := s.Truncations(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Truncations = %+v, want %+v", got, want)
+	}
+	if st := s.Stats(); st.Docs != 3 || st.TruncatedDocs != 6 {
+		t.Errorf("Stats = %+v, want 3 docs and 6 truncated", st)
+	}
+
+	if _, err := s.Search("resources", 10, namespacedDocs()[:3]); err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	if got := s.Truncations(); got != nil {
+		t.Errorf("Truncations after a catalog that fits = %+v, want nil", got)
+	}
+}
+
+func TestSearch_BudgetTruncationPolicy(t *testing.T) {
+	sizer := NewBM25Searcher(BM25Config{})
+	defer func() { _ = sizer.Close() }()
+	if _, err := sizer.Search("resources", 1, namespacedDocs()[:2]); err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+
+	s := NewBM25Searcher(BM25Config{
+		MaxIndexBytes: sizer.Stats().ApproxBytes,
+		BudgetMode:    BudgetTruncate,
+		Truncation:    TruncatePinned([]string{"zz:list"}, nil),
+	})
+	defer func() { _ = s.Close() }()
+	if _, err := s.Search("resources", 10, namespacedDocs()); err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	results, err := s.Search("", 10, namespacedDocs())
+	if err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	if len(results) == 0 || !slices.ContainsFunc(results, func(r toolindex.Summary) bool { return r.ID == "zz:list" }) {
+		t.Errorf("results = %v, want the pinned tool kept", results)
+	}
+	reports := s.Truncations()
+	if len(reports) != 1 || reports[0].Reason != TruncatedByMaxIndexBytes || reports[0].Kept != len(results) {
+		t.Errorf("Truncations = %+v", reports)
+	}
+}
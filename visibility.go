@@ -0,0 +1,133 @@
+package toolsearch
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search/query"
+	"github.com/jonwraymond/toolindex"
+)
+
+// Visibility restricts a search to the tools a caller may see, such as
+// the namespaces granted to a tenant or agent. Every non-empty list
+// narrows the visible set; the zero value, like a nil *Visibility, shows
+// every tool. Namespaces and tags match exactly.
+//
+// SearchWithOptions applies it inside the index query, so limit counts
+// visible tools only and hidden tools never appear in hits.
+type Visibility struct {
+	Namespaces        []string // visible namespaces
+	Tags              []string // a tool must carry at least one of these tags
+	ExcludeNamespaces []string // hidden namespaces, overriding Namespaces
+	ExcludeTags       []string // a tool carrying any of these tags is hidden
+}
+
+// Allows reports whether the tool described by s is visible.
+func (v *Visibility) Allows(s toolindex.Summary) bool {
+	if v == nil {
+		return true
+	}
+	if len(v.Namespaces) > 0 && !slices.Contains(v.Namespaces, s.Namespace) {
+		return false
+	}
+	if slices.Contains(v.ExcludeNamespaces, s.Namespace) {
+		return false
+	}
+	if len(v.Tags) > 0 && !slices.ContainsFunc(s.Tags, func(t string) bool { return slices.Contains(v.Tags, t) }) {
+		return false
+	}
+	return !slices.ContainsFunc(s.Tags, func(t string) bool { return slices.Contains(v.ExcludeTags, t) })
+}
+
+// FilterDocs returns the visible docs, for computing suggestions or facets
+// over the catalog a caller may see.
+func (v *Visibility) FilterDocs(docs []toolindex.SearchDoc) []toolindex.SearchDoc {
+	if v.unrestricted() {
+		return docs
+	}
+	out := make([]toolindex.SearchDoc, 0, len(docs))
+	for _, doc := range docs {
+		if v.Allows(doc.Summary) {
+			out = append(out, doc)
+		}
+	}
+	return out
+}
+
+func (v *Visibility) unrestricted() bool {
+	return v == nil || len(v.Namespaces)+len(v.Tags)+len(v.ExcludeNamespaces)+len(v.ExcludeTags) == 0
+}
+
+// filter returns a non-scoring index query matching visible docs, or nil
+// when every doc is visible.
+func (v *Visibility) filter() query.Query {
+	if v.unrestricted() {
+		return nil
+	}
+	q := bleve.NewBooleanQuery()
+	if len(v.Namespaces) > 0 {
+		q.AddMust(termsQuery(namespaceField, v.Namespaces))
+	}
+	if len(v.Tags) > 0 {
+		q.AddMust(termsQuery(tagsField, v.Tags))
+	}
+	if len(v.ExcludeNamespaces) > 0 {
+		q.AddMustNot(termsQuery(namespaceField, v.ExcludeNamespaces))
+	}
+	if len(v.ExcludeTags) > 0 {
+		q.AddMustNot(termsQuery(tagsField, v.ExcludeTags))
+	}
+	return q
+}
+
+// termsQuery matches docs whose field holds any of terms.
+func termsQuery(field string, terms []string) query.Query {
+	q := bleve.NewDisjunctionQuery()
+	for _, term := range terms {
+		tq := bleve.NewTermQuery(term)
+		tq.SetField(field)
+		q.AddQuery(tq)
+	}
+	return q
+}
+
+// key returns a canonical form of v for cache keys.
+func (v *Visibility) key() string {
+	if v.unrestricted() {
+		return ""
+	}
+	var sb strings.Builder
+	for _, list := range [][]string{v.Namespaces, v.Tags, v.ExcludeNamespaces, v.ExcludeTags} {
+		sorted := slices.Clone(list)
+		slices.Sort(sorted)
+		for _, s := range slices.Compact(sorted) {
+			sb.WriteString(s)
+			sb.WriteByte(1)
+		}
+		sb.WriteByte(2)
+	}
+	return sb.String()
+}
+
+// Fields of indexedDoc. Namespace and tags are indexed as exact keywords
+// for visibility filters; only content is ranked.
+const (
+	contentField   = "content"
+	namespaceField = "namespace"
+	tagsField      = "tags"
+)
+
+// newIndexMapping returns the mapping of indexedDoc.
+func newIndexMapping() mapping.IndexMapping {
+	m := bleve.NewIndexMapping()
+	for _, field := range []string{namespaceField, tagsField} {
+		fm := bleve.NewKeywordFieldMapping()
+		fm.Store = false
+		fm.IncludeInAll = false
+		fm.IncludeTermVectors = false
+		m.DefaultMapping.AddFieldMappingsAt(field, fm)
+	}
+	return m
+}
@@ -0,0 +1,169 @@
+package toolsearch
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/jonwraymond/toolindex"
+)
+
+// tenantDocs returns 10 "deploy" tools per namespace; the private
+// namespace's tools score highest.
+func tenantDocs() []toolindex.SearchDoc {
+	var docs []toolindex.SearchDoc
+	for _, ns := range []string{"private", "public", "shared"} {
+		for i := range 10 {
+			id := fmt.Sprintf("%s:deploy_%d", ns, i)
+			text := "deploy service"
+			if ns == "private" {
+				text = "deploy deploy deploy service"
+			}
+			tags := []string{"ops"}
+			if i%2 == 0 {
+				tags = append(tags, "internal")
+			}
+			docs = append(docs, toolindex.SearchDoc{
+				ID:      id,
+				DocText: text,
+				Summary: toolindex.Summary{ID: id, Name: fmt.Sprintf("deploy_%d", i), Namespace: ns, Tags: tags},
+			})
+		}
+	}
+	return docs
+}
+
+func TestVisibility_LimitCountsVisibleTools(t *testing.T) {
+	s := NewBM25Searcher(BM25Config{CacheSize: 16})
+	defer func() { _ = s.Close() }()
+	docs := tenantDocs()
+	vis := &Visibility{Namespaces: []string{"public", "shared"}, ExcludeTags: []string{"internal"}}
+
+	for _, query := range []string{"deploy", ""} {
+		result, err := s.SearchWithOptions(query, 8, docs, SearchOptions{Visibility: vis})
+		if err != nil {
+			t.Fatalf("search error: %v", err)
+		}
+		if len(result.Hits) != 8 {
+			t.Errorf("query %q: got %d hits, want a full page of 8 visible tools", query, len(result.Hits))
+		}
+		for _, hit := range result.Hits {
+			if !vis.Allows(hit.Summary) {
+				t.Errorf("query %q: hidden tool %s leaked", query, hit.Summary.ID)
+			}
+		}
+	}
+
+	// The same query without visibility is cached separately.
+	all, err := s.SearchWithOptions("deploy", 8, docs, SearchOptions{})
+	if err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	if all.Hits[0].Summary.Namespace != "private" {
+		t.Errorf("unfiltered top hit = %s, want a private tool", all.Hits[0].Summary.ID)
+	}
+}
+
+func TestVisibility_ScoresMatchUnfiltered(t *testing.T) {
+	s := NewBM25Searcher(BM25Config{})
+	defer func() { _ = s.Close() }()
+	docs := tenantDocs()
+
+	all, err := s.SearchWithOptions("deploy service", len(docs), docs, SearchOptions{})
+	if err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	vis := &Visibility{Tags: []string{"internal"}}
+	filtered, err := s.SearchWithOptions("deploy service", len(docs), docs, SearchOptions{Visibility: vis})
+	if err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+
+	var want []Hit
+	for _, hit := range all.Hits {
+		if vis.Allows(hit.Summary) {
+			want = append(want, hit)
+		}
+	}
+	if !reflect.DeepEqual(filtered.Hits, want) {
+		t.Errorf("filtered hits differ from filtering the full ranking:\n got %v\nwant %v", filtered.Hits, want)
+	}
+}
+
+func TestVisibility_HiddenToolsNeverLeak(t *testing.T) {
+	docs := tenantDocs()
+	cases := map[string]*Visibility{
+		"namespaces":         {Namespaces: []string{"shared"}},
+		"tags":               {Tags: []string{"internal"}},
+		"exclude namespaces": {ExcludeNamespaces: []string{"private", "shared"}},
+		"exclude tags":       {ExcludeTags: []string{"ops"}},
+		"unknown namespace":  {Namespaces: []string{"nope"}},
+	}
+	for name, vis := range cases {
+		t.Run(name, func(t *testing.T) {
+			s := NewBM25Searcher(BM25Config{})
+			defer func() { _ = s.Close() }()
+
+			visible := vis.FilterDocs(docs)
+			result, err := s.SearchWithOptions("deploy", len(docs), docs, SearchOptions{Visibility: vis})
+			if err != nil {
+				t.Fatalf("search error: %v", err)
+			}
+			if len(result.Hits) != len(visible) {
+				t.Errorf("got %d hits, want all %d visible tools", len(result.Hits), len(visible))
+			}
+			for _, hit := range result.Hits {
+				if !vis.Allows(hit.Summary) {
+					t.Errorf("hidden tool %s leaked into hits", hit.Summary.ID)
+				}
+			}
+
+			facets := ComputeFacets(result.Summaries())
+			for _, f := range facets.Namespaces {
+				if !slices.ContainsFunc(visible, func(d toolindex.SearchDoc) bool { return d.Summary.Namespace == f.Value }) {
+					t.Errorf("hidden namespace %q leaked into facets", f.Value)
+				}
+			}
+			for _, sg := range Suggest("p", 10, visible) {
+				if sg.Kind == SuggestionNamespace && !slices.ContainsFunc(visible, func(d toolindex.SearchDoc) bool { return d.Summary.Namespace == sg.Text }) {
+					t.Errorf("hidden namespace %q leaked into suggestions", sg.Text)
+				}
+			}
+		})
+	}
+}
+
+func TestVisibility_LiveUpdates(t *testing.T) {
+	live, idx := newLiveIndex(t, BM25Config{})
+	register(t, idx, liveTool("status", "git", "Show the working tree status"))
+	live.Follow(IndexSource(idx))
+	searchIDs(t, idx, "status")
+
+	secret := liveTool("status", "vault", "Show the seal status")
+	secret.Tags = []string{"secret"}
+	register(t, idx, secret)
+
+	result, err := live.SearchWithOptions("status", 10, nil, SearchOptions{Visibility: &Visibility{ExcludeTags: []string{"secret"}}})
+	if err != nil {
+		t.Fatalf("search error: %v", err)
+	}
+	if len(result.Hits) != 1 || result.Hits[0].Summary.ID != "git:status" {
+		t.Errorf("hits = %v, want only git:status", result.Hits)
+	}
+}
+
+func TestVisibility_Key(t *testing.T) {
+	a := &Visibility{Namespaces: []string{"b", "a", "a"}}
+	b := &Visibility{Namespaces: []string{"a", "b"}}
+	c := &Visibility{Tags: []string{"a", "b"}}
+	if a.key() != b.key() {
+		t.Error("equivalent visibilities should share a cache key")
+	}
+	if a.key() == c.key() {
+		t.Error("namespace and tag lists must not share a cache key")
+	}
+	if (&Visibility{}).key() != "" || (*Visibility)(nil).key() != "" {
+		t.Error("unrestricted visibility should have an empty key")
+	}
+}